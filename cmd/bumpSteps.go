@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/godrei/stepper/tools"
+	ver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagBumpStepsIncludes []string
+	flagWriteBumpSteps    bool
+	flagCheckBumpSteps    bool
+	flagDiffBumpSteps     bool
+	flagBumpStepsPolicy   string
+	flagBumpStepsMaxBump  string
+	flagBumpStepsHold     []string
+)
+
+var bumpStepsCmd = &cobra.Command{
+	Use:   "bumpSteps [path...]",
+	Short: "Bumps the step references (like git-clone@8) of bitrise.yml files to the latest step versions.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Keep stdout for the diff.
+		log.SetOutWriter(os.Stderr)
+
+		if err := bumpSteps(args); err != nil {
+			log.Errorf(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+// stepReferencePattern matches a step reference list item of a workflow, like '- git-clone@8:', '- "script@1.1.5": {}',
+// '- {git-clone@8: {}}', a bare '- git-clone@8' or '- https://github.com/bitrise-io/bitrise-steplib.git::git-clone@8.1:'.
+var stepReferencePattern = regexp.MustCompile(`^(\s*-\s+(?:\{\s*)?['"]?)((?:\S+::)?)([A-Za-z0-9][A-Za-z0-9_.-]*)@([0-9]+(?:\.[0-9]+){0,2})(['"]?(?:\s*:|\s*(?:#.*)?\s*$))`)
+
+// looseStepReferencePattern matches anything like a step reference in a list item or a steps list,
+// to report the references not recognised by stepReferencePattern, like 'steps: [git-clone@8]' or '- script@1.x:'.
+var looseStepReferencePattern = regexp.MustCompile(`(?:^|[\s\[{,'"])[A-Za-z0-9][A-Za-z0-9_.-]*@[0-9][^\s'":,}\]]*`)
+
+// pinnedVersionPattern matches a pinned step version, like 8, 8.1 or 8.1.0.
+var pinnedVersionPattern = regexp.MustCompile(`^[0-9]+(?:\.[0-9]+){0,2}$`)
+
+// stepReferenceBump is a step reference rewritten to a new version.
+type stepReferenceBump struct {
+	Path       string
+	Line       int
+	StepID     string
+	Version    string
+	NewVersion string
+}
+
+// stepVersionResolver resolves the pinned step versions to the versions allowed by the upgrade policy.
+type stepVersionResolver struct {
+	steplib models.StepCollectionModel
+	policy  UpgradePolicy
+
+	// warned and heldBack collect the steps, which are reported once, by step reference.
+	warned   map[string]bool
+	heldBack map[string]heldBackStep
+}
+
+func bumpSteps(paths []string) error {
+	if flagCheckBumpSteps && flagWriteBumpSteps {
+		return fmt.Errorf("check and write flags can not be used together")
+	}
+
+	policy, err := upgradePolicyFromFlags(flagBumpStepsPolicy, flagBumpStepsMaxBump, flagBumpStepsHold)
+	if err != nil {
+		return err
+	}
+
+	files, err := collectMatchingFiles(paths, flagBumpStepsIncludes)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files found matching %s", strings.Join(flagBumpStepsIncludes, ", "))
+	}
+
+	source, err := steplibSource()
+	if err != nil {
+		return err
+	}
+
+	steplib, err := source.Spec(tools.ExportTypesFull)
+	if err != nil {
+		return err
+	}
+
+	resolver := stepVersionResolver{
+		steplib:  steplib,
+		policy:   policy,
+		warned:   map[string]bool{},
+		heldBack: map[string]heldBackStep{},
+	}
+
+	var bumps []stepReferenceBump
+	bumpedFiles := 0
+	for _, pth := range files {
+		content, err := os.ReadFile(pth)
+		if err != nil {
+			return err
+		}
+
+		bumped, fileBumps := bumpStepReferences(pth, string(content), resolver.resolve)
+		if len(fileBumps) == 0 {
+			continue
+		}
+		bumps = append(bumps, fileBumps...)
+		bumpedFiles++
+
+		for _, bump := range fileBumps {
+			log.Printf("%s:%d: %s@%s -> %s@%s", bump.Path, bump.Line, bump.StepID, bump.Version, bump.StepID, bump.NewVersion)
+		}
+
+		if flagDiffBumpSteps {
+			fmt.Print(tools.UnifiedDiff(pth, pth, string(content), bumped))
+		}
+
+		if flagWriteBumpSteps {
+			if err := tools.WriteFileAtomically(pth, []byte(bumped)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(resolver.heldBack) > 0 {
+		log.Printf("")
+		log.Warnf("Held back step versions:")
+		var references []string
+		for reference := range resolver.heldBack {
+			references = append(references, reference)
+		}
+		sort.Strings(references)
+
+		for _, key := range references {
+			step := resolver.heldBack[key]
+			log.Printf("- %s: %s (latest: %s, %s)", key, step.Version, step.LatestVersion, step.Policy.Description())
+		}
+	}
+
+	switch {
+	case len(bumps) == 0:
+		log.Donef("%d file(s) are up to date", len(files))
+	case flagWriteBumpSteps:
+		log.Donef("Bumped %d step reference(s) in %d file(s)", len(bumps), bumpedFiles)
+	case flagCheckBumpSteps:
+		return fmt.Errorf("%d step reference(s) are outdated", len(bumps))
+	default:
+		log.Printf("")
+		log.Printf("%d step reference(s) are outdated, run with --write to bump them", len(bumps))
+	}
+
+	return nil
+}
+
+// collectMatchingFiles returns the given files and the files of the given dirs, whose name matches any of the include patterns.
+func collectMatchingFiles(paths, includes []string) ([]string, error) {
+	var files []string
+	for _, pth := range paths {
+		info, err := os.Stat(pth)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = appendUnique(files, pth)
+			continue
+		}
+
+		if err := filepath.WalkDir(pth, func(walkPth string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				switch entry.Name() {
+				case ".git", "node_modules", "vendor":
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			for _, include := range includes {
+				if match, err := filepath.Match(include, entry.Name()); err != nil {
+					return err
+				} else if match {
+					files = appendUnique(files, walkPth)
+					break
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// bumpStepReferences rewrites the versions of the step references in the content.
+// resolve returns the new version of a pinned step version, or false if the reference should be left as it is.
+func bumpStepReferences(pth, content string, resolve func(stepID, version string) (string, bool)) (string, []stepReferenceBump) {
+	var bumps []stepReferenceBump
+
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		match := stepReferencePattern.FindStringSubmatchIndex(line)
+		if match == nil {
+			if trimmed := strings.TrimSpace(line); (strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "steps:")) && looseStepReferencePattern.MatchString(trimmed) {
+				log.Warnf("%s:%d: unsupported step reference, the line is not bumped: %s", pth, i+1, trimmed)
+			}
+			continue
+		}
+
+		steplibURI := strings.TrimSuffix(line[match[4]:match[5]], "::")
+		if steplibURI != "" && steplibURI != defaultSteplibURI && steplibURI != flagSteplib {
+			// git::, path:: and other StepLib references
+			continue
+		}
+
+		stepID, version := line[match[6]:match[7]], line[match[8]:match[9]]
+		newVersion, ok := resolve(stepID, version)
+		if !ok || newVersion == version {
+			continue
+		}
+
+		lines[i] = line[:match[8]] + newVersion + line[match[9]:]
+		bumps = append(bumps, stepReferenceBump{Path: pth, Line: i + 1, StepID: stepID, Version: version, NewVersion: newVersion})
+	}
+
+	return strings.Join(lines, ""), bumps
+}
+
+// resolve returns the version allowed by the upgrade policy with the precision of the pinned version:
+// git-clone@8 is bumped to the latest major version (git-clone@9), git-clone@8.1 to git-clone@9.0.
+func (r stepVersionResolver) resolve(stepID, pinned string) (string, bool) {
+	reference := stepID + "@" + pinned
+
+	stepGroup, ok := r.steplib.Steps[stepID]
+	if !ok {
+		r.warnOnce(reference, "step (%s) not found in the StepLib, the reference is not bumped", stepID)
+		return "", false
+	}
+
+	var versions []string
+	for version := range stepGroup.Versions {
+		versions = append(versions, version)
+	}
+
+	current, err := highestMatchingVersion(versions, pinned)
+	if err != nil {
+		r.warnOnce(reference, "%s: %s", reference, err)
+		return "", false
+	}
+	if current == "" {
+		r.warnOnce(reference, "%s: no version found in the StepLib, the reference is not bumped", reference)
+		return "", false
+	}
+
+	stepPolicy := r.policy.StepPolicy(stepID)
+	allowed, err := stepPolicy.allowedVersion(current, versions)
+	if err != nil {
+		r.warnOnce(reference, "%s: %s", reference, err)
+		return "", false
+	}
+
+	precision := len(strings.Split(pinned, "."))
+	heldBack, ok, err := newHeldBackStep(stepID, stepPolicy, current, allowed, versions)
+	if err != nil {
+		r.warnOnce(reference, "%s: %s", reference, err)
+		return "", false
+	}
+	if ok && truncateVersion(heldBack.Version, precision) != truncateVersion(heldBack.LatestVersion, precision) {
+		heldBack.Version = truncateVersion(heldBack.Version, precision)
+		heldBack.LatestVersion = truncateVersion(heldBack.LatestVersion, precision)
+		r.heldBack[reference] = heldBack
+	}
+
+	return truncateVersion(allowed, precision), true
+}
+
+func (r stepVersionResolver) warnOnce(reference, format string, v ...interface{}) {
+	if r.warned[reference] {
+		return
+	}
+	r.warned[reference] = true
+	log.Warnf(format, v...)
+}
+
+// highestMatchingVersion returns the highest released (not prerelease) version, which starts with the pinned segments.
+func highestMatchingVersion(versions []string, pinned string) (string, error) {
+	var pinnedSegments []int64
+	for _, segment := range strings.Split(pinned, ".") {
+		s, err := strconv.ParseInt(segment, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid version (%s): %w", pinned, err)
+		}
+		pinnedSegments = append(pinnedSegments, s)
+	}
+
+	var matching []string
+	for _, version := range versions {
+		v, err := ver.NewVersion(version)
+		if err != nil {
+			return "", err
+		}
+		if v.Prerelease() != "" {
+			continue
+		}
+
+		segments := v.Segments64()
+		matches := true
+		for i, s := range pinnedSegments {
+			if i >= len(segments) || segments[i] != s {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			matching = append(matching, version)
+		}
+	}
+	if len(matching) == 0 {
+		return "", nil
+	}
+
+	sorted, err := tools.SortVersionsDesc(matching)
+	if err != nil {
+		return "", err
+	}
+	return sorted[0], nil
+}
+
+// truncateVersion keeps the first segments of the version, like 9.0 for 9.0.1 with precision 2.
+func truncateVersion(version string, precision int) string {
+	segments := strings.Split(version, ".")
+	if precision < len(segments) {
+		segments = segments[:precision]
+	}
+	return strings.Join(segments, ".")
+}
+
+func init() {
+	RootCmd.AddCommand(bumpStepsCmd)
+	bumpStepsCmd.Flags().StringArrayVarP(&flagBumpStepsIncludes, "include", "", []string{"bitrise.yml", "bitrise.yaml"}, "File name pattern of the files to bump in the given dirs, like '*.yml'. Can be specified multiple times.")
+	bumpStepsCmd.Flags().BoolVarP(&flagWriteBumpSteps, "write", "", false, "Update the files in place.")
+	bumpStepsCmd.Flags().BoolVarP(&flagCheckBumpSteps, "check", "", false, "Exit with an error if any step reference is outdated.")
+	bumpStepsCmd.Flags().BoolVarP(&flagDiffBumpSteps, "diff", "", false, "Print the unified diff of the file updates.")
+	bumpStepsCmd.Flags().StringVarP(&flagBumpStepsPolicy, "policy", "", "", "Path to the upgrade policy yaml file (see stepLatests).")
+	bumpStepsCmd.Flags().StringVarP(&flagBumpStepsMaxBump, "max-bump", "", "", "Largest allowed version bump of the steps: major, minor or patch. Overrides the policy file's max_bump.")
+	bumpStepsCmd.Flags().StringArrayVarP(&flagBumpStepsHold, "hold", "", nil, "ID of a step to keep on its current version. Can be specified multiple times.")
+}
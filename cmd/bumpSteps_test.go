@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/stepman/models"
+	"github.com/godrei/stepper/tools"
+)
+
+func TestBumpStepReferences(t *testing.T) {
+	latestVersions := map[string]string{
+		"git-clone": "9.0.1",
+		"script":    "1.2.0",
+	}
+	// resolve bumps to the latest version with the precision of the pinned version.
+	resolve := func(stepID, version string) (string, bool) {
+		latest, ok := latestVersions[stepID]
+		if !ok {
+			return "", false
+		}
+		return truncateVersion(latest, strings.Count(version, ".")+1), true
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		want      string
+		wantBumps []stepReferenceBump
+	}{
+		{
+			name:    "map item",
+			content: "steps:\n- git-clone@8:\n    inputs:\n    - clone_depth: 1\n",
+			want:    "steps:\n- git-clone@9:\n    inputs:\n    - clone_depth: 1\n",
+			wantBumps: []stepReferenceBump{
+				{Path: "bitrise.yml", Line: 2, StepID: "git-clone", Version: "8", NewVersion: "9"},
+			},
+		},
+		{
+			name:    "quoted reference with precision",
+			content: "    - \"script@1.1.5\": {}\n    - 'git-clone@8.1': {}\n",
+			want:    "    - \"script@1.2.0\": {}\n    - 'git-clone@9.0': {}\n",
+			wantBumps: []stepReferenceBump{
+				{Path: "bitrise.yml", Line: 1, StepID: "script", Version: "1.1.5", NewVersion: "1.2.0"},
+				{Path: "bitrise.yml", Line: 2, StepID: "git-clone", Version: "8.1", NewVersion: "9.0"},
+			},
+		},
+		{
+			name:    "bare and flow map items",
+			content: "- git-clone@8\n- script@1 # pinned major\n- {git-clone@8.0: {}}\n",
+			want:    "- git-clone@9\n- script@1 # pinned major\n- {git-clone@9.0: {}}\n",
+			wantBumps: []stepReferenceBump{
+				{Path: "bitrise.yml", Line: 1, StepID: "git-clone", Version: "8", NewVersion: "9"},
+				{Path: "bitrise.yml", Line: 3, StepID: "git-clone", Version: "8.0", NewVersion: "9.0"},
+			},
+		},
+		{
+			name:    "default StepLib reference",
+			content: "- https://github.com/bitrise-io/bitrise-steplib.git::git-clone@8:\n",
+			want:    "- https://github.com/bitrise-io/bitrise-steplib.git::git-clone@9:\n",
+			wantBumps: []stepReferenceBump{
+				{Path: "bitrise.yml", Line: 1, StepID: "git-clone", Version: "8", NewVersion: "9"},
+			},
+		},
+		{
+			name:    "not bumped references",
+			content: "- git::https://github.com/foo/bar.git@1.0:\n- path::./steps/my-step:\n- https://github.com/foo/steplib.git::git-clone@8:\n- script:\n- script@1.x:\n- unknown-step@1:\n- git-clone@9:\n",
+			want:    "- git::https://github.com/foo/bar.git@1.0:\n- path::./steps/my-step:\n- https://github.com/foo/steplib.git::git-clone@8:\n- script:\n- script@1.x:\n- unknown-step@1:\n- git-clone@9:\n",
+		},
+		{
+			name:    "not a list item",
+			content: "title: git-clone@8\nsummary: Uses node@18:\n",
+			want:    "title: git-clone@8\nsummary: Uses node@18:\n",
+		},
+		{
+			name:    "without trailing newline",
+			content: "- git-clone@8",
+			want:    "- git-clone@9",
+			wantBumps: []stepReferenceBump{
+				{Path: "bitrise.yml", Line: 1, StepID: "git-clone", Version: "8", NewVersion: "9"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, bumps := bumpStepReferences("bitrise.yml", tt.content, resolve)
+			if got != tt.want {
+				t.Errorf("bumpStepReferences() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !reflect.DeepEqual(bumps, tt.wantBumps) {
+				t.Errorf("bumpStepReferences() bumps = %+v, want %+v", bumps, tt.wantBumps)
+			}
+		})
+	}
+}
+
+func TestHighestMatchingVersion(t *testing.T) {
+	versions := []string{"8.0.0", "8.1.0", "8.1.2", "8.10.0", "9.0.0-beta.1", "7.2.0"}
+
+	tests := []struct {
+		pinned  string
+		want    string
+		wantErr bool
+	}{
+		{pinned: "8", want: "8.10.0"},
+		{pinned: "8.1", want: "8.1.2"},
+		{pinned: "8.1.0", want: "8.1.0"},
+		{pinned: "9", want: ""},
+		{pinned: "6", want: ""},
+		{pinned: "8.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pinned, func(t *testing.T) {
+			got, err := highestMatchingVersion(versions, tt.pinned)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("highestMatchingVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("highestMatchingVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		precision int
+		want      string
+	}{
+		{version: "9.0.1", precision: 1, want: "9"},
+		{version: "9.0.1", precision: 2, want: "9.0"},
+		{version: "9.0.1", precision: 3, want: "9.0.1"},
+		{version: "9.0", precision: 3, want: "9.0"},
+	}
+	for _, tt := range tests {
+		if got := truncateVersion(tt.version, tt.precision); got != tt.want {
+			t.Errorf("truncateVersion(%s, %d) = %s, want %s", tt.version, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestStepVersionResolver_Resolve(t *testing.T) {
+	stepGroup := func(versions ...string) models.StepGroupModel {
+		group := models.StepGroupModel{Versions: map[string]models.StepModel{}}
+		for _, version := range versions {
+			group.Versions[version] = models.StepModel{}
+		}
+		group.LatestVersionNumber = versions[len(versions)-1]
+		return group
+	}
+	steplib := models.StepCollectionModel{
+		Steps: models.StepHash{
+			"git-clone": stepGroup("8.0.0", "8.1.0", "8.1.1", "9.0.0"),
+			"script":    stepGroup("1.1.0", "1.2.0", "2.0.0-beta.1"),
+		},
+	}
+	policy := UpgradePolicy{MaxBump: tools.BumpTypeMinor}
+
+	tests := []struct {
+		name         string
+		stepID       string
+		pinned       string
+		want         string
+		wantHeldBack *heldBackStep
+	}{
+		{name: "major bump held back", stepID: "git-clone", pinned: "8.0.0", want: "8.1.1", wantHeldBack: &heldBackStep{StepID: "git-clone", Version: "8.1.1", LatestVersion: "9.0.0", Policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMinor}}},
+		{name: "major bump held back with the pinned precision", stepID: "git-clone", pinned: "8", want: "8", wantHeldBack: &heldBackStep{StepID: "git-clone", Version: "8", LatestVersion: "9", Policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMinor}}},
+		{name: "prerelease latest version not held back", stepID: "script", pinned: "1.1", want: "1.2"},
+		{name: "latest version", stepID: "git-clone", pinned: "9.0", want: "9.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := stepVersionResolver{steplib: steplib, policy: policy, warned: map[string]bool{}, heldBack: map[string]heldBackStep{}}
+			got, ok := resolver.resolve(tt.stepID, tt.pinned)
+			if !ok || got != tt.want {
+				t.Fatalf("resolve() = (%s, %v), want (%s, true)", got, ok, tt.want)
+			}
+
+			heldBack, held := resolver.heldBack[tt.stepID+"@"+tt.pinned]
+			if held != (tt.wantHeldBack != nil) || (held && heldBack != *tt.wantHeldBack) {
+				t.Errorf("resolve() held back = (%+v, %v), want %+v", heldBack, held, tt.wantHeldBack)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+const defaultGitHubHost = "github.com"
+
+// GitHubHost is a GitHub or GitHub Enterprise instance hosting step repositories.
+type GitHubHost struct {
+	// Host is the host of the repository URLs, like github.com or github.mycompany.com.
+	Host string
+	// APIURL is the base URL of the REST API, like https://api.github.com/ or https://github.mycompany.com/api/v3/.
+	APIURL string
+	Token  string
+}
+
+// parseGitHubHosts creates the GitHub host configs from the 'host=value' (or only 'value' for github.com) API URL and token mappings.
+// The hosts without an API URL use the GitHub Enterprise default: https://<host>/api/v3/.
+func parseGitHubHosts(apiURLs, tokens []string) (map[string]GitHubHost, error) {
+	hosts := map[string]GitHubHost{
+		defaultGitHubHost: {Host: defaultGitHubHost},
+	}
+
+	for _, mapping := range apiURLs {
+		host, apiURL := splitHostMapping(mapping)
+		if _, err := url.ParseRequestURI(apiURL); err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL (%s): %w", apiURL, err)
+		}
+		h := hosts[host]
+		h.Host = host
+		h.APIURL = apiURL
+		hosts[host] = h
+	}
+
+	for _, mapping := range tokens {
+		host, token := splitHostMapping(mapping)
+		h := hosts[host]
+		h.Host = host
+		h.Token = token
+		hosts[host] = h
+	}
+
+	for host, h := range hosts {
+		if h.APIURL == "" && host != defaultGitHubHost {
+			h.APIURL = fmt.Sprintf("https://%s/api/v3/", host)
+			hosts[host] = h
+		}
+	}
+
+	return hosts, nil
+}
+
+// splitHostMapping splits a 'host=value' mapping, a value without host belongs to github.com.
+// URL values are only split at a '=' before the scheme, like 'github.mycompany.com=https://github.mycompany.com/api/v3/'.
+func splitHostMapping(mapping string) (string, string) {
+	host, value, found := strings.Cut(mapping, "=")
+	if !found || strings.Contains(host, "/") || strings.Contains(host, ":") {
+		return defaultGitHubHost, mapping
+	}
+	return strings.ToLower(host), value
+}
+
+// splitEnvList splits a comma separated env var value.
+func splitEnvList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newGitHubClients creates an API client for every GitHub host.
+func newGitHubClients(hosts map[string]GitHubHost) (map[string]*github.Client, error) {
+	clients := map[string]*github.Client{}
+	for host, h := range hosts {
+		var httpClient *http.Client
+		if h.Token != "" {
+			tokenSource := oauth2.StaticTokenSource(
+				&oauth2.Token{AccessToken: h.Token},
+			)
+			httpClient = oauth2.NewClient(context.Background(), tokenSource)
+		}
+
+		if h.APIURL == "" {
+			clients[host] = github.NewClient(httpClient)
+			continue
+		}
+
+		apiURL := h.APIURL
+		if !strings.HasSuffix(apiURL, "/") {
+			apiURL += "/"
+		}
+		baseURL, err := url.Parse(apiURL)
+		if err != nil {
+			return nil, err
+		}
+
+		client := github.NewClient(httpClient)
+		client.BaseURL = baseURL
+		clients[host] = client
+	}
+	return clients, nil
+}
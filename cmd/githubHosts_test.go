@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitHostMapping(t *testing.T) {
+	tests := []struct {
+		mapping   string
+		wantHost  string
+		wantValue string
+	}{
+		{mapping: "ghp_token", wantHost: "github.com", wantValue: "ghp_token"},
+		{mapping: "GitHub.MyCompany.com=ghe_token", wantHost: "github.mycompany.com", wantValue: "ghe_token"},
+		{mapping: "https://api.example.com/", wantHost: "github.com", wantValue: "https://api.example.com/"},
+		{mapping: "github.mycompany.com=https://github.mycompany.com/api/v3/", wantHost: "github.mycompany.com", wantValue: "https://github.mycompany.com/api/v3/"},
+		{mapping: "https://api.example.com/?token=abc", wantHost: "github.com", wantValue: "https://api.example.com/?token=abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mapping, func(t *testing.T) {
+			host, value := splitHostMapping(tt.mapping)
+			if host != tt.wantHost || value != tt.wantValue {
+				t.Errorf("splitHostMapping() = (%s, %s), want (%s, %s)", host, value, tt.wantHost, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestParseGitHubHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiURLs []string
+		tokens  []string
+		want    map[string]GitHubHost
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: map[string]GitHubHost{
+				"github.com": {Host: "github.com"},
+			},
+		},
+		{
+			name:   "github.com token",
+			tokens: []string{"ghp_token"},
+			want: map[string]GitHubHost{
+				"github.com": {Host: "github.com", Token: "ghp_token"},
+			},
+		},
+		{
+			name:    "github.com API URL",
+			apiURLs: []string{"http://127.0.0.1:18080/"},
+			want: map[string]GitHubHost{
+				"github.com": {Host: "github.com", APIURL: "http://127.0.0.1:18080/"},
+			},
+		},
+		{
+			name:   "enterprise host with default API URL",
+			tokens: []string{"ghp_token", "github.mycompany.com=ghe_token"},
+			want: map[string]GitHubHost{
+				"github.com":           {Host: "github.com", Token: "ghp_token"},
+				"github.mycompany.com": {Host: "github.mycompany.com", APIURL: "https://github.mycompany.com/api/v3/", Token: "ghe_token"},
+			},
+		},
+		{
+			name:    "enterprise host with API URL",
+			apiURLs: []string{"git.example.com=https://api.git.example.com/"},
+			tokens:  []string{"git.example.com=ghe_token"},
+			want: map[string]GitHubHost{
+				"github.com":      {Host: "github.com"},
+				"git.example.com": {Host: "git.example.com", APIURL: "https://api.git.example.com/", Token: "ghe_token"},
+			},
+		},
+		{
+			name:    "invalid API URL",
+			apiURLs: []string{"git.example.com=api.git.example.com"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGitHubHosts(tt.apiURLs, tt.tokens)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGitHubHosts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGitHubHosts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// NoteCategory ...
+type NoteCategory string
+
+const (
+	// NoteCategoryBreaking ...
+	NoteCategoryBreaking NoteCategory = "breaking"
+	// NoteCategoryFeatures ...
+	NoteCategoryFeatures NoteCategory = "features"
+	// NoteCategoryFixes ...
+	NoteCategoryFixes NoteCategory = "fixes"
+	// NoteCategoryDependencies ...
+	NoteCategoryDependencies NoteCategory = "dependencies"
+	// NoteCategoryOther ...
+	NoteCategoryOther NoteCategory = "other"
+)
+
+// noteCategories lists the categories in the order of the report sections.
+var noteCategories = []NoteCategory{
+	NoteCategoryBreaking,
+	NoteCategoryFeatures,
+	NoteCategoryFixes,
+	NoteCategoryDependencies,
+	NoteCategoryOther,
+}
+
+// Title ...
+func (c NoteCategory) Title() string {
+	switch c {
+	case NoteCategoryBreaking:
+		return "Breaking changes"
+	case NoteCategoryFeatures:
+		return "Features"
+	case NoteCategoryFixes:
+		return "Fixes"
+	case NoteCategoryDependencies:
+		return "Dependencies"
+	default:
+		return "Other"
+	}
+}
+
+// NoteSection is the notes of a category.
+type NoteSection struct {
+	Category NoteCategory `json:"category"`
+	Title    string       `json:"title"`
+	Notes    []string     `json:"notes"`
+}
+
+// CategoryRules assigns the release notes to categories.
+//
+// A note is categorised by its conventional commit prefix (like 'feat:' or 'fix(scope)!:') first,
+// then by the labels of its pull request, finally by the keywords it contains (case insensitive).
+// The notes without a matching rule go to the other category.
+// The rules are built by DefaultCategoryRules and ReadCategoryRules, which compile the keyword patterns.
+type CategoryRules struct {
+	Keywords map[NoteCategory][]string `yaml:"keywords"`
+	Labels   map[NoteCategory][]string `yaml:"labels"`
+
+	// keywordPatterns are the compiled patterns of the keywords.
+	keywordPatterns map[NoteCategory][]*regexp.Regexp
+}
+
+// DefaultCategoryRules ...
+func DefaultCategoryRules() CategoryRules {
+	rules := CategoryRules{
+		Keywords: map[NoteCategory][]string{
+			NoteCategoryBreaking:     {"breaking", "removed", "drop support"},
+			NoteCategoryDependencies: {"update dependency", "bump", "upgrade", "dependencies"},
+			NoteCategoryFeatures:     {"add", "new", "support", "introduce", "feature"},
+			NoteCategoryFixes:        {"fix", "bug", "crash", "resolve", "correct"},
+		},
+		Labels: map[NoteCategory][]string{
+			NoteCategoryBreaking:     {"breaking", "breaking-change", "breaking change"},
+			NoteCategoryDependencies: {"dependencies", "deps", "renovate", "dependabot"},
+			NoteCategoryFeatures:     {"feature", "enhancement"},
+			NoteCategoryFixes:        {"bug", "fix", "bugfix"},
+		},
+	}
+	rules.compileKeywordPatterns()
+	return rules
+}
+
+// ReadCategoryRules reads the rules from a yaml file, the categories defined in the file replace the default rules.
+func ReadCategoryRules(pth string) (CategoryRules, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return CategoryRules{}, err
+	}
+
+	var fileRules CategoryRules
+	if err := yaml.UnmarshalStrict(content, &fileRules); err != nil {
+		return CategoryRules{}, fmt.Errorf("invalid category rules (%s): %w", pth, err)
+	}
+
+	rules := DefaultCategoryRules()
+	for category, keywords := range fileRules.Keywords {
+		if err := validateNoteCategory(category); err != nil {
+			return CategoryRules{}, err
+		}
+		rules.Keywords[category] = keywords
+	}
+	for category, labels := range fileRules.Labels {
+		if err := validateNoteCategory(category); err != nil {
+			return CategoryRules{}, err
+		}
+		rules.Labels[category] = labels
+	}
+	rules.compileKeywordPatterns()
+
+	return rules, nil
+}
+
+func (r *CategoryRules) compileKeywordPatterns() {
+	r.keywordPatterns = map[NoteCategory][]*regexp.Regexp{}
+	for category, keywords := range r.Keywords {
+		for _, keyword := range keywords {
+			r.keywordPatterns[category] = append(r.keywordPatterns[category], keywordPattern(keyword))
+		}
+	}
+}
+
+func validateNoteCategory(category NoteCategory) error {
+	for _, c := range noteCategories {
+		if c == category {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid category (%s), available: [breaking, features, fixes, dependencies, other]", category)
+}
+
+var conventionalNotePattern = regexp.MustCompile(`(?i)^(\w+)(\(([^)]*)\))?(!)?:\s*`)
+
+// Categorize returns the category of the note and the note without its conventional commit prefix.
+func (r CategoryRules) Categorize(note string, labels []string) (NoteCategory, string) {
+	if match := conventionalNotePattern.FindStringSubmatch(note); match != nil {
+		if category, ok := conventionalCommitCategory(strings.ToLower(match[1]), strings.ToLower(match[3]), match[4] == "!"); ok {
+			return category, lowerCharacterFirst(note[len(match[0]):])
+		}
+	}
+	if strings.Contains(note, "BREAKING CHANGE") {
+		return NoteCategoryBreaking, note
+	}
+
+	for _, category := range noteCategories {
+		for _, label := range labels {
+			if containsFold(r.Labels[category], label) {
+				return category, note
+			}
+		}
+	}
+
+	// The keyword closest to the start of the note wins, like in 'fix crash when adding a file'.
+	lowerNote := strings.ToLower(note)
+	category, position := NoteCategoryOther, -1
+	for _, c := range noteCategories {
+		for _, pattern := range r.keywordPatterns[c] {
+			loc := pattern.FindStringIndex(lowerNote)
+			if loc != nil && (position == -1 || loc[0] < position) {
+				category, position = c, loc[0]
+			}
+		}
+	}
+
+	return category, note
+}
+
+func conventionalCommitCategory(commitType, scope string, breaking bool) (NoteCategory, bool) {
+	if breaking {
+		return NoteCategoryBreaking, true
+	}
+	if scope == "deps" || strings.HasPrefix(scope, "deps-") {
+		return NoteCategoryDependencies, true
+	}
+
+	switch commitType {
+	case "feat":
+		return NoteCategoryFeatures, true
+	case "fix", "perf":
+		return NoteCategoryFixes, true
+	case "deps":
+		return NoteCategoryDependencies, true
+	case "chore", "ci", "build", "docs", "style", "refactor", "test", "revert":
+		return NoteCategoryOther, true
+	}
+	return "", false
+}
+
+// keywordPattern matches the keyword at a word start, so 'add' matches 'added', but not 'padding'.
+func keywordPattern(keyword string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|\W)` + regexp.QuoteMeta(strings.ToLower(keyword)))
+}
+
+func containsFold(items []string, item string) bool {
+	for _, i := range items {
+		if strings.EqualFold(i, item) {
+			return true
+		}
+	}
+	return false
+}
+
+var dependencyBotNotePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^(chore|build|fix)\(deps(-dev)?\)`),
+	regexp.MustCompile(`(?i)^bump \S+ from \S+ to \S+`),
+	regexp.MustCompile(`(?i)^update (dependency|module|golang\.org|github\.com|\S+ digest)`),
+	regexp.MustCompile(`(?i)^(update|pin) .+ to v?[0-9a-f][\w.-]*( \(#\d+\))?$`),
+	regexp.MustCompile(`(?i)\b(renovate|dependabot)(\[bot\])?\b`),
+	regexp.MustCompile(`(?i)^lock file maintenance`),
+}
+
+// isDependencyBotNote reports whether the note is a dependency update of Renovate or Dependabot.
+func isDependencyBotNote(note string) bool {
+	for _, pattern := range dependencyBotNotePatterns {
+		if pattern.MatchString(note) {
+			return true
+		}
+	}
+	return false
+}
+
+// categorizeNotes groups the notes by category in the order of the report sections.
+func categorizeNotes(rules CategoryRules, notes []string, labelsByNote map[string][]string) []NoteSection {
+	notesByCategory := map[NoteCategory][]string{}
+	for _, note := range notes {
+		category, text := rules.Categorize(note, labelsByNote[note])
+		notesByCategory[category] = append(notesByCategory[category], text)
+	}
+
+	var sections []NoteSection
+	for _, category := range noteCategories {
+		if len(notesByCategory[category]) == 0 {
+			continue
+		}
+		sections = append(sections, NoteSection{Category: category, Title: category.Title(), Notes: notesByCategory[category]})
+	}
+	return sections
+}
+
+// mergeNoteSections merges the sections of multiple releases by category.
+func mergeNoteSections(sectionLists ...[]NoteSection) []NoteSection {
+	notesByCategory := map[NoteCategory][]string{}
+	for _, sections := range sectionLists {
+		for _, section := range sections {
+			notesByCategory[section.Category] = append(notesByCategory[section.Category], section.Notes...)
+		}
+	}
+
+	var merged []NoteSection
+	for _, category := range noteCategories {
+		if len(notesByCategory[category]) == 0 {
+			continue
+		}
+		merged = append(merged, NoteSection{Category: category, Title: category.Title(), Notes: notesByCategory[category]})
+	}
+	return merged
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCategoryRules_Categorize(t *testing.T) {
+	tests := []struct {
+		name         string
+		note         string
+		labels       []string
+		wantCategory NoteCategory
+		wantNote     string
+	}{
+		{name: "conventional feature", note: "feat: Add shallow clone input", wantCategory: NoteCategoryFeatures, wantNote: "add shallow clone input"},
+		{name: "conventional fix with scope", note: "fix(submodules): handle missing .gitmodules", wantCategory: NoteCategoryFixes, wantNote: "handle missing .gitmodules"},
+		{name: "conventional breaking", note: "feat(api)!: drop the clone_depth input", wantCategory: NoteCategoryBreaking, wantNote: "drop the clone_depth input"},
+		{name: "conventional deps scope", note: "chore(deps): update go-utils", wantCategory: NoteCategoryDependencies, wantNote: "update go-utils"},
+		{name: "conventional chore", note: "ci: run the e2e tests on Linux", wantCategory: NoteCategoryOther, wantNote: "run the e2e tests on Linux"},
+		{name: "unknown conventional type", note: "Note: the input is required", wantCategory: NoteCategoryOther, wantNote: "Note: the input is required"},
+		{name: "breaking change footer", note: "Rework inputs BREAKING CHANGE: removed clone_depth", wantCategory: NoteCategoryBreaking, wantNote: "Rework inputs BREAKING CHANGE: removed clone_depth"},
+		{name: "pull request label", note: "Shallow clone (#123)", labels: []string{"Enhancement"}, wantCategory: NoteCategoryFeatures, wantNote: "Shallow clone (#123)"},
+		{name: "label before keyword", note: "Fix the clone of forks", labels: []string{"dependencies"}, wantCategory: NoteCategoryDependencies, wantNote: "Fix the clone of forks"},
+		{name: "keyword", note: "Added support for LFS", wantCategory: NoteCategoryFeatures, wantNote: "Added support for LFS"},
+		{name: "first keyword wins", note: "Fix crash when adding a file", wantCategory: NoteCategoryFixes, wantNote: "Fix crash when adding a file"},
+		{name: "keyword at word start only", note: "Padding of the log lines", wantCategory: NoteCategoryOther, wantNote: "Padding of the log lines"},
+		{name: "no rule", note: "Documentation", wantCategory: NoteCategoryOther, wantNote: "Documentation"},
+	}
+	rules := DefaultCategoryRules()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, note := rules.Categorize(tt.note, tt.labels)
+			if category != tt.wantCategory || note != tt.wantNote {
+				t.Errorf("Categorize() = (%s, %s), want (%s, %s)", category, note, tt.wantCategory, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestReadCategoryRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		note         string
+		labels       []string
+		wantCategory NoteCategory
+		wantErr      bool
+	}{
+		{
+			name:         "replaced keywords",
+			content:      "keywords:\n  features: [\"enable\"]\n",
+			note:         "Enable caching",
+			wantCategory: NoteCategoryFeatures,
+		},
+		{
+			name:         "default keywords of the replaced category are dropped",
+			content:      "keywords:\n  features: [\"enable\"]\n",
+			note:         "Added caching",
+			wantCategory: NoteCategoryOther,
+		},
+		{
+			name:         "default keywords of the other categories are kept",
+			content:      "keywords:\n  features: [\"enable\"]\n",
+			note:         "Fixed caching",
+			wantCategory: NoteCategoryFixes,
+		},
+		{
+			name:         "replaced labels",
+			content:      "labels:\n  breaking: [\"major\"]\n",
+			note:         "Caching",
+			labels:       []string{"major"},
+			wantCategory: NoteCategoryBreaking,
+		},
+		{
+			name:    "invalid category",
+			content: "keywords:\n  docs: [\"readme\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			content: "patterns:\n  fixes: [\"fix\"]\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "rules.yml")
+			if err := os.WriteFile(pth, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := ReadCategoryRules(pth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadCategoryRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if category, _ := rules.Categorize(tt.note, tt.labels); category != tt.wantCategory {
+				t.Errorf("Categorize() = %s, want %s", category, tt.wantCategory)
+			}
+		})
+	}
+}
+
+func TestCategorizeNotes(t *testing.T) {
+	notes := []string{"fix: handle empty tags", "feat: add LFS support", "Bump go-utils", "fix: retry the fetch"}
+	want := []NoteSection{
+		{Category: NoteCategoryFeatures, Title: "Features", Notes: []string{"add LFS support"}},
+		{Category: NoteCategoryFixes, Title: "Fixes", Notes: []string{"handle empty tags", "retry the fetch"}},
+		{Category: NoteCategoryDependencies, Title: "Dependencies", Notes: []string{"Bump go-utils"}},
+	}
+
+	if got := categorizeNotes(DefaultCategoryRules(), notes, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("categorizeNotes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsDependencyBotNote(t *testing.T) {
+	tests := []struct {
+		note string
+		want bool
+	}{
+		{note: "chore(deps): update dependency go to v1.21", want: true},
+		{note: "Bump golang.org/x/net from 0.7.0 to 0.17.0 (#45)", want: true},
+		{note: "Update module github.com/bitrise-io/go-utils to v1.0.9", want: true},
+		{note: "Lock file maintenance", want: true},
+		{note: "Merge pull request #12 from renovate/configure", want: true},
+		{note: "Update the README", want: false},
+		{note: "fix: bump the default clone depth", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.note, func(t *testing.T) {
+			if got := isDependencyBotNote(tt.note); got != tt.want {
+				t.Errorf("isDependencyBotNote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// NotesProviderType ...
+type NotesProviderType string
+
+const (
+	// NotesProviderGitHub reads the notes from the GitHub releases.
+	NotesProviderGitHub NotesProviderType = "github"
+	// NotesProviderGit builds the notes from the commit messages of the local step repository clones.
+	NotesProviderGit NotesProviderType = "git"
+)
+
+// ReleaseNotesSourceGit means the notes are the commit messages between the previous and the released version tag.
+const ReleaseNotesSourceGit ReleaseNotesSource = "git"
+
+// NotesRequest identifies the release notes of a step version.
+type NotesRequest struct {
+	RepoURL string
+	Version string
+	// PreviousVersion is the version released before, empty if this is the first version of the step.
+	PreviousVersion string
+}
+
+// NotesProvider looks up the release notes of step versions.
+type NotesProvider interface {
+	Notes(ctx context.Context, requests []NotesRequest) map[NotesRequest]ReleaseLookup
+}
+
+// PullRequestLabeler is a NotesProvider, which can tell the labels of the pull requests referenced in the notes.
+type PullRequestLabeler interface {
+	// PullRequestLabels returns the labels of the pull request referenced by each note, like 'Add input (#123)'.
+	PullRequestLabels(ctx context.Context, repoURL string, notes []string) map[string][]string
+}
+
+// GitNotesProvider clones (or reuses the clones of) the step repositories
+// and builds the release notes from the commit messages between the previous and the released version tag.
+// It works with any git host and, with already cloned repositories, without network access.
+type GitNotesProvider struct {
+	// ReposDir is the dir of the repository clones.
+	ReposDir string
+	// Offline uses the existing clones without fetching them.
+	Offline bool
+	// ConventionalCommits keeps only the user facing conventional commits (feat, fix, perf, revert and breaking changes).
+	ConventionalCommits bool
+	Concurrency         int
+}
+
+// DefaultReposDir returns the default dir of the step repository clones.
+func DefaultReposDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, "stepper", "step-repos"), nil
+}
+
+// Notes ...
+func (p GitNotesProvider) Notes(ctx context.Context, requests []NotesRequest) map[NotesRequest]ReleaseLookup {
+	requestsByRepo := map[string][]NotesRequest{}
+	for _, request := range requests {
+		requestsByRepo[request.RepoURL] = append(requestsByRepo[request.RepoURL], request)
+	}
+
+	concurrency := p.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		lookups = map[NotesRequest]ReleaseLookup{}
+		wg      sync.WaitGroup
+	)
+
+	jobs := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repoURL := range jobs {
+				repoLookups := p.repoNotes(ctx, repoURL, requestsByRepo[repoURL])
+
+				mu.Lock()
+				for request, lookup := range repoLookups {
+					lookups[request] = lookup
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for repoURL := range requestsByRepo {
+		jobs <- repoURL
+	}
+	close(jobs)
+	wg.Wait()
+
+	return lookups
+}
+
+func (p GitNotesProvider) repoNotes(ctx context.Context, repoURL string, requests []NotesRequest) map[NotesRequest]ReleaseLookup {
+	lookups := map[NotesRequest]ReleaseLookup{}
+
+	dir, err := p.syncRepo(ctx, repoURL)
+	if err != nil {
+		status := ReleaseLookupNetworkError
+		if p.Offline {
+			status = ReleaseLookupFailed
+		}
+		for _, request := range requests {
+			lookups[request] = ReleaseLookup{Status: status, Err: err}
+		}
+		return lookups
+	}
+
+	for _, request := range requests {
+		lookups[request] = p.releaseNotes(ctx, dir, request)
+	}
+	return lookups
+}
+
+// syncRepo clones the repository or fetches the existing clone and returns the clone's dir.
+func (p GitNotesProvider) syncRepo(ctx context.Context, repoURL string) (string, error) {
+	dir, err := p.repoDir(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	exists, err := pathutil.IsDirExists(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		if p.Offline {
+			return dir, nil
+		}
+		if out, err := gitCommand(ctx, dir, "fetch", "--quiet", "--tags", "--force", "origin", "+refs/heads/*:refs/heads/*"); err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %s: %w", repoURL, out, err)
+		}
+		return dir, nil
+	}
+
+	if p.Offline {
+		return "", fmt.Errorf("repository (%s) is not cloned", repoURL)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+	if out, err := gitCommand(ctx, "", "clone", "--quiet", "--bare", repoURL, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to clone %s: %s: %w", repoURL, out, err)
+	}
+	return dir, nil
+}
+
+// repoDir returns the clone's dir of the repository, like <repos dir>/github.com/bitrise-steplib/steps-git-clone.git.
+func (p GitNotesProvider) repoDir(repoURL string) (string, error) {
+	u, err := parseRepoURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if u.Host == "" || repoPath == "" || strings.Contains(repoPath, "..") {
+		return "", fmt.Errorf("invalid repository url: %s", repoURL)
+	}
+
+	return filepath.Join(p.ReposDir, u.Host, filepath.FromSlash(repoPath)+".git"), nil
+}
+
+// scpLikeURLPattern matches the scp-like git URLs, like git@github.com:bitrise-steplib/steps-git-clone.git.
+var scpLikeURLPattern = regexp.MustCompile(`^(?:([^@/:]+)@)?([^@/:]+):([^/].*)$`)
+
+// parseRepoURL parses a repository URL, the scp-like git URLs are parsed as ssh URLs.
+func parseRepoURL(repoURL string) (*url.URL, error) {
+	if !strings.Contains(repoURL, "://") {
+		if match := scpLikeURLPattern.FindStringSubmatch(repoURL); match != nil {
+			u := &url.URL{Scheme: "ssh", Host: match[2], Path: "/" + match[3]}
+			if match[1] != "" {
+				u.User = url.User(match[1])
+			}
+			return u, nil
+		}
+	}
+
+	return url.Parse(repoURL)
+}
+
+func (p GitNotesProvider) releaseNotes(ctx context.Context, dir string, request NotesRequest) ReleaseLookup {
+	tag, found := resolveTag(ctx, dir, request.Version)
+	if !found {
+		return ReleaseLookup{Status: ReleaseLookupNotFound}
+	}
+
+	revisionRange := tag
+	if request.PreviousVersion != "" {
+		previousTag, found := resolveTag(ctx, dir, request.PreviousVersion)
+		if !found {
+			return ReleaseLookup{Status: ReleaseLookupFailed, Err: fmt.Errorf("tag of the previous version (%s) not found", request.PreviousVersion)}
+		}
+		revisionRange = previousTag + ".." + tag
+	}
+
+	out, err := gitCommand(ctx, dir, "log", "--no-merges", "--format=%s", revisionRange)
+	if err != nil {
+		return ReleaseLookup{Status: ReleaseLookupFailed, Err: fmt.Errorf("%s: %w", out, err)}
+	}
+
+	var notes []string
+	for _, subject := range strings.Split(out, "\n") {
+		subject = strings.TrimSpace(subject)
+		if subject == "" {
+			continue
+		}
+		if p.ConventionalCommits && !isUserFacingConventionalCommit(subject) {
+			continue
+		}
+		notes = append(notes, "- "+subject)
+	}
+
+	return ReleaseLookup{
+		Release: Release{Body: strings.Join(notes, "\n"), Source: ReleaseNotesSourceGit},
+		Status:  ReleaseLookupFound,
+	}
+}
+
+// resolveTag returns the tag of the version, the tags might have a 'v' prefix.
+func resolveTag(ctx context.Context, dir, version string) (string, bool) {
+	for _, tag := range []string{version, "v" + version} {
+		if _, err := gitCommand(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/tags/"+tag+"^{commit}"); err == nil {
+			return "refs/tags/" + tag, true
+		}
+	}
+	return "", false
+}
+
+var conventionalCommitPattern = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?: `)
+
+// isUserFacingConventionalCommit reports whether the commit subject is a feature, fix, performance improvement,
+// revert or breaking change in the conventional commit format (https://www.conventionalcommits.org).
+func isUserFacingConventionalCommit(subject string) bool {
+	match := conventionalCommitPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return false
+	}
+	if match[3] == "!" {
+		return true
+	}
+
+	switch strings.ToLower(match[1]) {
+	case "feat", "fix", "perf", "revert":
+		return true
+	}
+	return false
+}
+
+func gitCommand(ctx context.Context, dir string, args ...string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	cmd := command.New("git", args...)
+	if dir != "" {
+		cmd.SetDir(dir)
+	}
+	cmd.AppendEnvs("GIT_TERMINAL_PROMPT=0")
+
+	log.Debugf("$ %s", cmd.PrintableCommandArgs())
+	return cmd.RunAndReturnTrimmedCombinedOutput()
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGitNotesProvider_RepoDir(t *testing.T) {
+	reposDir := t.TempDir()
+	provider := GitNotesProvider{ReposDir: reposDir}
+
+	tests := []struct {
+		repoURL string
+		want    string
+		wantErr bool
+	}{
+		{repoURL: "https://github.com/bitrise-steplib/steps-git-clone.git", want: "github.com/bitrise-steplib/steps-git-clone.git"},
+		{repoURL: "https://github.com/bitrise-steplib/steps-git-clone", want: "github.com/bitrise-steplib/steps-git-clone.git"},
+		{repoURL: "ssh://git@github.com/bitrise-steplib/steps-git-clone.git", want: "github.com/bitrise-steplib/steps-git-clone.git"},
+		{repoURL: "git@github.com:bitrise-steplib/steps-git-clone.git", want: "github.com/bitrise-steplib/steps-git-clone.git"},
+		{repoURL: "gitlab.example.com:group/sub/step.git", want: "gitlab.example.com/group/sub/step.git"},
+		{repoURL: "https://github.com/", wantErr: true},
+		{repoURL: "https://github.com/../../etc", wantErr: true},
+		{repoURL: "./steps/local", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repoURL, func(t *testing.T) {
+			got, err := provider.repoDir(tt.repoURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("repoDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := filepath.Join(reposDir, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("repoDir() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		repoURL  string
+		wantHost string
+		wantPath string
+		wantUser string
+	}{
+		{repoURL: "https://github.com/bitrise-steplib/steps-git-clone.git", wantHost: "github.com", wantPath: "/bitrise-steplib/steps-git-clone.git"},
+		{repoURL: "git@github.com:bitrise-steplib/steps-git-clone.git", wantHost: "github.com", wantPath: "/bitrise-steplib/steps-git-clone.git", wantUser: "git"},
+		{repoURL: "github.com:bitrise-steplib/steps-git-clone.git", wantHost: "github.com", wantPath: "/bitrise-steplib/steps-git-clone.git"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repoURL, func(t *testing.T) {
+			u, err := parseRepoURL(tt.repoURL)
+			if err != nil {
+				t.Fatalf("parseRepoURL() error = %v", err)
+			}
+			if u.Host != tt.wantHost || u.Path != tt.wantPath || u.User.Username() != tt.wantUser {
+				t.Errorf("parseRepoURL() = (%s, %s, %s), want (%s, %s, %s)", u.Host, u.Path, u.User.Username(), tt.wantHost, tt.wantPath, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,570 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/godrei/stepper/tools"
+	"github.com/google/go-github/github"
+)
+
+const (
+	maxReleaseFetchAttempts = 5
+	releaseFetchBackoff     = time.Second
+	maxReleaseFetchBackoff  = time.Minute
+)
+
+// ReleaseKey identifies a GitHub release.
+type ReleaseKey struct {
+	Host  string
+	Owner string
+	Repo  string
+	Tag   string
+}
+
+// ReleaseNotesSource ...
+type ReleaseNotesSource string
+
+const (
+	// ReleaseNotesSourceRelease means the notes are the body of the GitHub release.
+	ReleaseNotesSourceRelease ReleaseNotesSource = "release"
+	// ReleaseNotesSourceTag means the notes are the message of the annotated tag.
+	ReleaseNotesSourceTag ReleaseNotesSource = "tag"
+	// ReleaseNotesSourceChangelog means the notes are the version's section of the CHANGELOG.md.
+	ReleaseNotesSourceChangelog ReleaseNotesSource = "changelog"
+)
+
+// Release is the part of a GitHub release used by the reports.
+type Release struct {
+	Body    string             `json:"body"`
+	HTMLURL string             `json:"html_url"`
+	Source  ReleaseNotesSource `json:"source"`
+}
+
+// ReleaseLookupStatus ...
+type ReleaseLookupStatus string
+
+const (
+	// ReleaseLookupFound ...
+	ReleaseLookupFound ReleaseLookupStatus = "found"
+	// ReleaseLookupNotFound means neither the release, nor the fallback notes exist.
+	ReleaseLookupNotFound ReleaseLookupStatus = "not_found"
+	// ReleaseLookupAuthFailed means the API token is invalid or has no access to the repository.
+	ReleaseLookupAuthFailed ReleaseLookupStatus = "auth_failed"
+	// ReleaseLookupRateLimited means the request was still rate limited after the retries.
+	ReleaseLookupRateLimited ReleaseLookupStatus = "rate_limited"
+	// ReleaseLookupNetworkError means the request failed before a response was received.
+	ReleaseLookupNetworkError ReleaseLookupStatus = "network_error"
+	// ReleaseLookupFailed means an unexpected response, like a server error.
+	ReleaseLookupFailed ReleaseLookupStatus = "failed"
+	// ReleaseLookupUnsupportedHost means the step repository is not hosted on a configured GitHub host.
+	ReleaseLookupUnsupportedHost ReleaseLookupStatus = "unsupported_host"
+)
+
+// ReleaseLookup is the result of looking up the notes of a release.
+type ReleaseLookup struct {
+	Release Release
+	Status  ReleaseLookupStatus
+	// Err is the error of the failed lookup, nil if the release was found or does not exist.
+	Err error
+}
+
+// Reason describes why the release notes are missing.
+func (l ReleaseLookup) Reason() string {
+	switch l.Status {
+	case ReleaseLookupNotFound:
+		return "no release found"
+	case ReleaseLookupAuthFailed:
+		return "authentication failed"
+	case ReleaseLookupRateLimited:
+		return "rate limited"
+	case ReleaseLookupNetworkError:
+		return "network error"
+	case ReleaseLookupUnsupportedHost:
+		return "not a GitHub repository"
+	}
+	if l.Err != nil {
+		return l.Err.Error()
+	}
+	return string(l.Status)
+}
+
+// ReleaseFetcher fetches GitHub releases with a bounded number of concurrent requests,
+// retries the rate limited requests and caches the fetched releases on the disk.
+//
+// If a tag has no GitHub release, the fetcher can fall back to the message of the annotated tag
+// and to the version's section of the CHANGELOG.md at the tag.
+type ReleaseFetcher struct {
+	// clients are the API clients of the GitHub hosts.
+	clients     map[string]*github.Client
+	cache       ReleaseCache
+	concurrency int
+	fallback    bool
+}
+
+// NewReleaseFetcher ...
+func NewReleaseFetcher(clients map[string]*github.Client, cache ReleaseCache, concurrency int, fallback bool) ReleaseFetcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return ReleaseFetcher{
+		clients:     clients,
+		cache:       cache,
+		concurrency: concurrency,
+		fallback:    fallback,
+	}
+}
+
+// Notes looks up the GitHub releases of the requested step versions.
+func (f ReleaseFetcher) Notes(ctx context.Context, requests []NotesRequest) map[NotesRequest]ReleaseLookup {
+	lookups := map[NotesRequest]ReleaseLookup{}
+	keyByRequest := map[NotesRequest]ReleaseKey{}
+
+	var keys []ReleaseKey
+	for _, request := range requests {
+		key, err := releaseKeyFromURL(request.RepoURL, request.Version)
+		if err != nil {
+			lookups[request] = ReleaseLookup{Status: ReleaseLookupFailed, Err: err}
+			continue
+		}
+		keyByRequest[request] = key
+		keys = append(keys, key)
+	}
+
+	releaseLookups := f.FetchAll(ctx, keys)
+	for request, key := range keyByRequest {
+		lookups[request] = releaseLookups[key]
+	}
+	return lookups
+}
+
+// FetchAll looks up the given releases.
+func (f ReleaseFetcher) FetchAll(ctx context.Context, keys []ReleaseKey) map[ReleaseKey]ReleaseLookup {
+	var (
+		mu      sync.Mutex
+		lookups = map[ReleaseKey]ReleaseLookup{}
+		wg      sync.WaitGroup
+	)
+
+	jobs := make(chan ReleaseKey)
+	for i := 0; i < f.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				lookup := f.Fetch(ctx, key)
+
+				mu.Lock()
+				lookups[key] = lookup
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+
+	return lookups
+}
+
+// Fetch looks up a release in the cache or on GitHub.
+func (f ReleaseFetcher) Fetch(ctx context.Context, key ReleaseKey) ReleaseLookup {
+	if release, found, err := f.cache.Get(key); err != nil {
+		log.Warnf("Failed to read cached release (%s/%s@%s): %s", key.Owner, key.Repo, key.Tag, err)
+	} else if found {
+		return ReleaseLookup{Release: release, Status: ReleaseLookupFound}
+	}
+
+	if f.cache.Offline {
+		return ReleaseLookup{Status: ReleaseLookupFailed, Err: fmt.Errorf("release is not cached (offline)")}
+	}
+
+	if _, ok := f.clients[key.Host]; !ok {
+		return ReleaseLookup{Status: ReleaseLookupUnsupportedHost}
+	}
+
+	lookup := f.fetchRelease(ctx, key)
+	if lookup.Status == ReleaseLookupNotFound && f.fallback {
+		lookup = f.fetchTagAnnotation(ctx, key)
+		if lookup.Status == ReleaseLookupNotFound {
+			lookup = f.fetchChangelog(ctx, key)
+		}
+	}
+
+	if lookup.Status == ReleaseLookupFound {
+		if err := f.cache.Set(key, lookup.Release); err != nil {
+			log.Warnf("Failed to cache release (%s/%s@%s): %s", key.Owner, key.Repo, key.Tag, err)
+		}
+	}
+
+	return lookup
+}
+
+func (f ReleaseFetcher) fetchRelease(ctx context.Context, key ReleaseKey) ReleaseLookup {
+	var githubRelease *github.RepositoryRelease
+	status, err := f.do(ctx, key, func() (*github.Response, error) {
+		var response *github.Response
+		var err error
+		githubRelease, response, err = f.clients[key.Host].Repositories.GetReleaseByTag(ctx, key.Owner, key.Repo, key.Tag)
+		return response, err
+	})
+	if status != ReleaseLookupFound {
+		return ReleaseLookup{Status: status, Err: err}
+	}
+
+	return ReleaseLookup{
+		Release: Release{Body: githubRelease.GetBody(), HTMLURL: githubRelease.GetHTMLURL(), Source: ReleaseNotesSourceRelease},
+		Status:  ReleaseLookupFound,
+	}
+}
+
+func (f ReleaseFetcher) fetchTagAnnotation(ctx context.Context, key ReleaseKey) ReleaseLookup {
+	var ref *github.Reference
+	status, err := f.do(ctx, key, func() (*github.Response, error) {
+		var response *github.Response
+		var err error
+		ref, response, err = f.clients[key.Host].Git.GetRef(ctx, key.Owner, key.Repo, "tags/"+key.Tag)
+		return response, err
+	})
+	if status != ReleaseLookupFound {
+		return ReleaseLookup{Status: status, Err: err}
+	}
+
+	// Lightweight tags point to the commit directly, only the annotated tags have a message.
+	if ref.GetObject().GetType() != "tag" {
+		return ReleaseLookup{Status: ReleaseLookupNotFound}
+	}
+
+	var tag *github.Tag
+	status, err = f.do(ctx, key, func() (*github.Response, error) {
+		var response *github.Response
+		var err error
+		tag, response, err = f.clients[key.Host].Git.GetTag(ctx, key.Owner, key.Repo, ref.GetObject().GetSHA())
+		return response, err
+	})
+	if status != ReleaseLookupFound {
+		return ReleaseLookup{Status: status, Err: err}
+	}
+
+	message := strings.TrimSpace(tag.GetMessage())
+	if message == "" || message == key.Tag {
+		return ReleaseLookup{Status: ReleaseLookupNotFound}
+	}
+
+	return ReleaseLookup{
+		Release: Release{Body: message, HTMLURL: fmt.Sprintf("https://%s/%s/%s/releases/tag/%s", key.Host, key.Owner, key.Repo, key.Tag), Source: ReleaseNotesSourceTag},
+		Status:  ReleaseLookupFound,
+	}
+}
+
+func (f ReleaseFetcher) fetchChangelog(ctx context.Context, key ReleaseKey) ReleaseLookup {
+	var file *github.RepositoryContent
+	status, err := f.do(ctx, key, func() (*github.Response, error) {
+		var response *github.Response
+		var err error
+		file, _, response, err = f.clients[key.Host].Repositories.GetContents(ctx, key.Owner, key.Repo, "CHANGELOG.md", &github.RepositoryContentGetOptions{Ref: key.Tag})
+		return response, err
+	})
+	if status != ReleaseLookupFound {
+		return ReleaseLookup{Status: status, Err: err}
+	}
+	if file == nil {
+		return ReleaseLookup{Status: ReleaseLookupNotFound}
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return ReleaseLookup{Status: ReleaseLookupFailed, Err: err}
+	}
+
+	section, found := changelogSection(content, key.Tag)
+	if !found {
+		return ReleaseLookup{Status: ReleaseLookupNotFound}
+	}
+
+	return ReleaseLookup{
+		Release: Release{Body: section, HTMLURL: file.GetHTMLURL(), Source: ReleaseNotesSourceChangelog},
+		Status:  ReleaseLookupFound,
+	}
+}
+
+// changelogSection returns the content of the version's section in a markdown changelog,
+// the section starts with a heading containing the version, like '## [1.2.0] - 2024-01-01' or '### v1.2.0'.
+func changelogSection(content, version string) (string, bool) {
+	versionPattern := regexp.MustCompile(`(^|[^0-9.])v?` + regexp.QuoteMeta(strings.TrimPrefix(version, "v")) + `($|[^0-9.])`)
+
+	var section []string
+	level := 0
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		headingLevel := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+
+		if level == 0 {
+			if headingLevel > 0 && versionPattern.MatchString(trimmed[headingLevel:]) {
+				level = headingLevel
+			}
+			continue
+		}
+
+		if headingLevel > 0 && headingLevel <= level {
+			break
+		}
+		// Sub-headings, like '### Fixes', are not release notes.
+		if headingLevel > 0 {
+			continue
+		}
+		section = append(section, line)
+	}
+
+	if level == 0 {
+		return "", false
+	}
+	return strings.TrimSpace(strings.Join(section, "\n")), true
+}
+
+// do sends the request, retries it while it is rate limited and classifies the failure.
+func (f ReleaseFetcher) do(ctx context.Context, key ReleaseKey, request func() (*github.Response, error)) (ReleaseLookupStatus, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := request()
+		if err == nil {
+			return ReleaseLookupFound, nil
+		}
+
+		delay, retryable := retryDelay(response, err, attempt, time.Now())
+		if !retryable {
+			status := releaseLookupStatus(response)
+			if status == ReleaseLookupNotFound {
+				return status, nil
+			}
+			return status, err
+		}
+		if attempt+1 >= maxReleaseFetchAttempts {
+			return ReleaseLookupRateLimited, err
+		}
+
+		log.Warnf("Rate limited while fetching release (%s/%s@%s), retrying in %s", key.Owner, key.Repo, key.Tag, delay.Round(time.Second))
+		if err := sleepContext(ctx, delay); err != nil {
+			return ReleaseLookupNetworkError, err
+		}
+	}
+}
+
+// releaseLookupStatus classifies a failed, not retryable request.
+func releaseLookupStatus(response *github.Response) ReleaseLookupStatus {
+	if response == nil {
+		return ReleaseLookupNetworkError
+	}
+
+	switch response.StatusCode {
+	case http.StatusNotFound:
+		return ReleaseLookupNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ReleaseLookupAuthFailed
+	}
+
+	// The request succeeded, but the response does not describe a single object, like multiple matching refs.
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		return ReleaseLookupNotFound
+	}
+
+	return ReleaseLookupFailed
+}
+
+var pullRequestReferencePattern = regexp.MustCompile(`(?:#|/pull/)(\d+)\b`)
+
+// pullRequestNumber returns the number of the first pull request referenced by the note, like 123 for 'Add input (#123)'.
+func pullRequestNumber(note string) (int, bool) {
+	match := pullRequestReferencePattern.FindStringSubmatch(note)
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// PullRequestLabels fetches the labels of the pull requests referenced by the notes.
+// The labels are not cached, so offline no labels are returned.
+func (f ReleaseFetcher) PullRequestLabels(ctx context.Context, repoURL string, notes []string) map[string][]string {
+	labelsByNote := map[string][]string{}
+	if f.cache.Offline {
+		return labelsByNote
+	}
+
+	key, err := releaseKeyFromURL(repoURL, "")
+	if err != nil {
+		return labelsByNote
+	}
+	if _, ok := f.clients[key.Host]; !ok {
+		return labelsByNote
+	}
+
+	labelsByNumber := map[int][]string{}
+	for _, note := range notes {
+		number, ok := pullRequestNumber(note)
+		if !ok {
+			continue
+		}
+
+		labels, ok := labelsByNumber[number]
+		if !ok {
+			var issue *github.Issue
+			status, err := f.do(ctx, key, func() (*github.Response, error) {
+				var response *github.Response
+				var err error
+				issue, response, err = f.clients[key.Host].Issues.Get(ctx, key.Owner, key.Repo, number)
+				return response, err
+			})
+			if status == ReleaseLookupFound {
+				for _, label := range issue.Labels {
+					labels = append(labels, label.GetName())
+				}
+			} else if err != nil {
+				log.Warnf("Failed to fetch the labels of %s/%s#%d: %s", key.Owner, key.Repo, number, err)
+			}
+			labelsByNumber[number] = labels
+		}
+
+		labelsByNote[note] = labels
+	}
+
+	return labelsByNote
+}
+
+// retryDelay returns how long to wait before retrying a failed request and whether the request can be retried.
+// Rate limited requests (403 and 429) wait until the X-RateLimit-Reset time or the Retry-After duration,
+// if these are not available, they are retried with exponential backoff.
+func retryDelay(response *github.Response, err error, attempt int, now time.Time) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return untilReset(rateLimitErr.Rate.Reset.Time, attempt, now), true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return backoff(attempt), true
+	}
+
+	if response == nil {
+		return 0, false
+	}
+	if response.StatusCode != http.StatusForbidden && response.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	// A 403 without rate limit headers is an authorization error.
+	if response.StatusCode == http.StatusForbidden && response.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+
+	return untilReset(response.Rate.Reset.Time, attempt, now), true
+}
+
+func untilReset(reset time.Time, attempt int, now time.Time) time.Duration {
+	if reset.IsZero() || !reset.After(now) {
+		return backoff(attempt)
+	}
+	// The reset time has a second precision.
+	return reset.Sub(now) + time.Second
+}
+
+func backoff(attempt int) time.Duration {
+	delay := releaseFetchBackoff << uint(attempt)
+	if delay > maxReleaseFetchBackoff || delay <= 0 {
+		return maxReleaseFetchBackoff
+	}
+	return delay
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReleaseCache stores the fetched releases on the disk, keyed by host/owner/repo/tag.
+// Only the found releases are cached, a missing release might be published later.
+type ReleaseCache struct {
+	// Dir is the root dir of the cache, an empty dir disables the cache.
+	Dir string
+	// Refresh ignores the cached releases, but still caches the fetched ones.
+	Refresh bool
+	// Offline uses only the cached releases without calling the GitHub API.
+	Offline bool
+}
+
+// DefaultReleaseCacheDir returns the default dir of the release cache.
+func DefaultReleaseCacheDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, "stepper", "github-releases"), nil
+}
+
+// Get ...
+func (c ReleaseCache) Get(key ReleaseKey) (Release, bool, error) {
+	if c.Dir == "" || c.Refresh {
+		return Release{}, false, nil
+	}
+
+	content, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Release{}, false, nil
+		}
+		return Release{}, false, err
+	}
+
+	var release Release
+	if err := json.Unmarshal(content, &release); err != nil {
+		return Release{}, false, err
+	}
+	return release, true, nil
+}
+
+// Set ...
+func (c ReleaseCache) Set(key ReleaseKey, release Release) error {
+	if c.Dir == "" {
+		return nil
+	}
+
+	pth := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(release)
+	if err != nil {
+		return err
+	}
+	return tools.WriteFileAtomically(pth, content)
+}
+
+func (c ReleaseCache) path(key ReleaseKey) string {
+	return filepath.Join(c.Dir, key.Host, key.Owner, key.Repo, key.Tag+".json")
+}
@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	retryAfter := 30 * time.Second
+
+	response := func(statusCode int, remaining string, reset time.Time) *github.Response {
+		header := http.Header{}
+		if remaining != "" {
+			header.Set("X-RateLimit-Remaining", remaining)
+		}
+		return &github.Response{
+			Response: &http.Response{StatusCode: statusCode, Header: header},
+			Rate:     github.Rate{Reset: github.Timestamp{Time: reset}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		response      *github.Response
+		err           error
+		attempt       int
+		wantDelay     time.Duration
+		wantRetryable bool
+	}{
+		{
+			name:          "rate limit error waits until the reset",
+			err:           &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: now.Add(90 * time.Second)}}},
+			wantDelay:     91 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "rate limit error with passed reset backs off",
+			err:           &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: now.Add(-time.Second)}}},
+			attempt:       2,
+			wantDelay:     4 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "secondary rate limit waits the retry after duration",
+			err:           &github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			wantDelay:     30 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "secondary rate limit without retry after backs off",
+			err:           &github.AbuseRateLimitError{},
+			attempt:       1,
+			wantDelay:     2 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "403 with exhausted rate limit waits until the reset",
+			response:      response(http.StatusForbidden, "0", now.Add(10*time.Second)),
+			err:           errors.New("403 API rate limit exceeded"),
+			wantDelay:     11 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:     "403 without rate limit headers is not retried",
+			response: response(http.StatusForbidden, "", time.Time{}),
+			err:      errors.New("403 Resource not accessible by integration"),
+		},
+		{
+			name:     "403 with remaining rate limit is not retried",
+			response: response(http.StatusForbidden, "4999", now.Add(10*time.Second)),
+			err:      errors.New("403 Forbidden"),
+		},
+		{
+			name:          "429 without reset backs off",
+			response:      response(http.StatusTooManyRequests, "", time.Time{}),
+			err:           errors.New("429 Too Many Requests"),
+			wantDelay:     time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "backoff is capped",
+			response:      response(http.StatusTooManyRequests, "", time.Time{}),
+			err:           errors.New("429 Too Many Requests"),
+			attempt:       10,
+			wantDelay:     maxReleaseFetchBackoff,
+			wantRetryable: true,
+		},
+		{
+			name:     "not found is not retried",
+			response: response(http.StatusNotFound, "", time.Time{}),
+			err:      errors.New("404 Not Found"),
+		},
+		{
+			name: "network error is not retried",
+			err:  errors.New("dial tcp: connection refused"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retryable := retryDelay(tt.response, tt.err, tt.attempt, now)
+			if delay != tt.wantDelay || retryable != tt.wantRetryable {
+				t.Errorf("retryDelay() = (%s, %v), want (%s, %v)", delay, retryable, tt.wantDelay, tt.wantRetryable)
+			}
+		})
+	}
+}
+
+func TestReleaseFetcher_FetchAll(t *testing.T) {
+	const concurrency = 3
+
+	var requests, inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			peak := atomic.LoadInt32(&maxInFlight)
+			if current <= peak || atomic.CompareAndSwapInt32(&maxInFlight, peak, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		tag, ok := strings.CutPrefix(r.URL.Path, "/repos/bitrise-steplib/steps-git-clone/releases/tags/")
+		if !ok || tag == "9.9.9" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"body": "Release %s", "html_url": "https://github.com/bitrise-steplib/steps-git-clone/releases/tag/%s"}`, tag, tag)
+	}))
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := github.NewClient(nil)
+	client.BaseURL = baseURL
+
+	var keys []ReleaseKey
+	for i := 0; i < 10; i++ {
+		keys = append(keys, ReleaseKey{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-git-clone", Tag: fmt.Sprintf("8.%d.0", i)})
+	}
+	missingKey := ReleaseKey{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-git-clone", Tag: "9.9.9"}
+	otherHostKey := ReleaseKey{Host: "gitlab.com", Owner: "bitrise-steplib", Repo: "steps-git-clone", Tag: "8.0.0"}
+
+	cache := ReleaseCache{Dir: t.TempDir()}
+	fetcher := NewReleaseFetcher(map[string]*github.Client{"github.com": client}, cache, concurrency, false)
+
+	lookups := fetcher.FetchAll(context.Background(), append(append([]ReleaseKey{}, keys...), missingKey, otherHostKey))
+	for _, key := range keys {
+		lookup := lookups[key]
+		if lookup.Status != ReleaseLookupFound || lookup.Release.Body != "Release "+key.Tag {
+			t.Errorf("FetchAll() %s = %+v, want found", key.Tag, lookup)
+		}
+	}
+	if status := lookups[missingKey].Status; status != ReleaseLookupNotFound {
+		t.Errorf("FetchAll() missing release status = %s, want %s", status, ReleaseLookupNotFound)
+	}
+	if status := lookups[otherHostKey].Status; status != ReleaseLookupUnsupportedHost {
+		t.Errorf("FetchAll() other host status = %s, want %s", status, ReleaseLookupUnsupportedHost)
+	}
+	if peak := atomic.LoadInt32(&maxInFlight); peak < 2 || peak > concurrency {
+		t.Errorf("FetchAll() concurrent requests = %d, want 2-%d", peak, concurrency)
+	}
+
+	// The found releases are served from the cache, the missing one is requested again.
+	requestsBefore := atomic.LoadInt32(&requests)
+	lookups = fetcher.FetchAll(context.Background(), append(append([]ReleaseKey{}, keys...), missingKey))
+	if got := atomic.LoadInt32(&requests) - requestsBefore; got != 1 {
+		t.Errorf("FetchAll() requests with cache = %d, want 1", got)
+	}
+	if lookups[keys[0]].Status != ReleaseLookupFound {
+		t.Errorf("FetchAll() cached release = %+v, want found", lookups[keys[0]])
+	}
+
+	// Refresh ignores the cached releases.
+	refreshingFetcher := NewReleaseFetcher(map[string]*github.Client{"github.com": client}, ReleaseCache{Dir: cache.Dir, Refresh: true}, concurrency, false)
+	requestsBefore = atomic.LoadInt32(&requests)
+	refreshingFetcher.FetchAll(context.Background(), keys)
+	if got := atomic.LoadInt32(&requests) - requestsBefore; got != int32(len(keys)) {
+		t.Errorf("FetchAll() requests with refresh = %d, want %d", got, len(keys))
+	}
+}
+
+func TestReleaseLookupStatus(t *testing.T) {
+	response := func(statusCode int) *github.Response {
+		return &github.Response{Response: &http.Response{StatusCode: statusCode}}
+	}
+
+	tests := []struct {
+		name     string
+		response *github.Response
+		want     ReleaseLookupStatus
+	}{
+		{name: "network error", response: nil, want: ReleaseLookupNetworkError},
+		{name: "not found", response: response(http.StatusNotFound), want: ReleaseLookupNotFound},
+		{name: "unauthorized", response: response(http.StatusUnauthorized), want: ReleaseLookupAuthFailed},
+		{name: "forbidden", response: response(http.StatusForbidden), want: ReleaseLookupAuthFailed},
+		{name: "rate limited", response: response(http.StatusTooManyRequests), want: ReleaseLookupFailed},
+		{name: "server error", response: response(http.StatusBadGateway), want: ReleaseLookupFailed},
+		{name: "unexpected success", response: response(http.StatusOK), want: ReleaseLookupNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := releaseLookupStatus(tt.response); got != tt.want {
+				t.Errorf("releaseLookupStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseFetcher_Do(t *testing.T) {
+	noWait := time.Duration(0)
+	key := ReleaseKey{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-git-clone", Tag: "8.0.0"}
+	response := func(statusCode int) *github.Response {
+		return &github.Response{Response: &http.Response{StatusCode: statusCode, Header: http.Header{}}}
+	}
+
+	tests := []struct {
+		name         string
+		response     *github.Response
+		err          error
+		want         ReleaseLookupStatus
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "found", want: ReleaseLookupFound, wantAttempts: 1},
+		{name: "not found", response: response(http.StatusNotFound), err: errors.New("404 Not Found"), want: ReleaseLookupNotFound, wantAttempts: 1},
+		{name: "auth failed", response: response(http.StatusUnauthorized), err: errors.New("401 Bad credentials"), want: ReleaseLookupAuthFailed, wantAttempts: 1, wantErr: true},
+		{name: "rate limited", err: &github.AbuseRateLimitError{RetryAfter: &noWait}, want: ReleaseLookupRateLimited, wantAttempts: maxReleaseFetchAttempts, wantErr: true},
+		{name: "network error", err: errors.New("dial tcp: connection refused"), want: ReleaseLookupNetworkError, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			status, err := ReleaseFetcher{}.do(context.Background(), key, func() (*github.Response, error) {
+				attempts++
+				return tt.response, tt.err
+			})
+			if status != tt.want || (err != nil) != tt.wantErr || attempts != tt.wantAttempts {
+				t.Errorf("do() = (%s, %v) after %d attempts, want (%s, error %v) after %d attempts", status, err, attempts, tt.want, tt.wantErr, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestChangelogSection(t *testing.T) {
+	const changelog = `# Changelog
+
+## [1.2.0] - 2024-03-10
+
+### Fixes
+
+- Fix the clone depth (#12)
+
+## 1.1.0 - 2024-02-01
+
+- Add the merge input
+
+## [1.0.10]
+
+- Initial release
+`
+
+	tests := []struct {
+		version string
+		want    string
+		wantOK  bool
+	}{
+		{version: "1.2.0", want: "- Fix the clone depth (#12)", wantOK: true},
+		{version: "v1.1.0", want: "- Add the merge input", wantOK: true},
+		{version: "1.0.10", want: "- Initial release", wantOK: true},
+		{version: "1.0.1"},
+		{version: "2.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, ok := changelogSection(changelog, tt.version)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("changelogSection() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPullRequestNumber(t *testing.T) {
+	tests := []struct {
+		note   string
+		want   int
+		wantOK bool
+	}{
+		{note: "Add the merge input (#123)", want: 123, wantOK: true},
+		{note: "Fix the clone depth in https://github.com/bitrise-steplib/steps-git-clone/pull/45", want: 45, wantOK: true},
+		{note: "Fix #7 and #8", want: 7, wantOK: true},
+		{note: "Release 8.1.0"},
+		{note: "Add #hashtag support"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.note, func(t *testing.T) {
+			got, ok := pullRequestNumber(tt.note)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("pullRequestNumber() = (%d, %v), want (%d, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReleaseFetcher_PullRequestLabels(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/repos/bitrise-steplib/steps-git-clone/issues/12":
+			fmt.Fprint(w, `{"number": 12, "labels": [{"name": "bug"}]}`)
+		case "/repos/bitrise-steplib/steps-git-clone/issues/13":
+			fmt.Fprint(w, `{"number": 13, "labels": [{"name": "Enhancement"}, {"name": "docs"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := github.NewClient(nil)
+	client.BaseURL = baseURL
+	clients := map[string]*github.Client{"github.com": client}
+
+	const repoURL = "https://github.com/bitrise-steplib/steps-git-clone"
+	notes := []string{"Clone depth update (#12)", "Merge input (#13)", "Merge input docs (#13)", "Release cleanup (#99)", "Release notes"}
+
+	fetcher := NewReleaseFetcher(clients, ReleaseCache{Dir: t.TempDir()}, 2, false)
+	labelsByNote := fetcher.PullRequestLabels(context.Background(), repoURL, notes)
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("PullRequestLabels() requests = %d, want 3", got)
+	}
+
+	want := []NoteSection{
+		{Category: NoteCategoryFeatures, Title: "Features", Notes: []string{"Merge input (#13)", "Merge input docs (#13)"}},
+		{Category: NoteCategoryFixes, Title: "Fixes", Notes: []string{"Clone depth update (#12)"}},
+		{Category: NoteCategoryOther, Title: "Other", Notes: []string{"Release cleanup (#99)", "Release notes"}},
+	}
+	if got := categorizeNotes(DefaultCategoryRules(), notes, labelsByNote); !reflect.DeepEqual(got, want) {
+		t.Errorf("categorizeNotes() = %+v, want %+v", got, want)
+	}
+
+	offlineFetcher := NewReleaseFetcher(clients, ReleaseCache{Dir: t.TempDir(), Offline: true}, 2, false)
+	requestsBefore := atomic.LoadInt32(&requests)
+	if got := offlineFetcher.PullRequestLabels(context.Background(), repoURL, notes); len(got) != 0 {
+		t.Errorf("PullRequestLabels() offline = %v, want no labels", got)
+	}
+	if got := atomic.LoadInt32(&requests) - requestsBefore; got != 0 {
+		t.Errorf("PullRequestLabels() offline requests = %d, want 0", got)
+	}
+}
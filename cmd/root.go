@@ -2,11 +2,22 @@ package cmd
 
 import (
 	"os"
+	"time"
 
 	"github.com/bitrise-io/go-utils/log"
+	"github.com/godrei/stepper/tools"
 	"github.com/spf13/cobra"
 )
 
+const defaultSteplibURI = "https://github.com/bitrise-io/bitrise-steplib.git"
+
+var (
+	flagSteplib     string
+	flagSpecMaxAge  time.Duration
+	flagOffline     bool
+	flagRefreshSpec bool
+)
+
 // RootCmd ...
 var RootCmd = &cobra.Command{
 	Use:   "stepper",
@@ -20,3 +31,29 @@ func Execute() {
 		os.Exit(-1)
 	}
 }
+
+func init() {
+	RootCmd.PersistentFlags().StringVarP(&flagSteplib, "steplib", "", defaultSteplibURI, "StepLib to use: a StepLib collection URI (requires stepman), a local StepLib checkout directory or a spec.json file exported by stepman.")
+	RootCmd.PersistentFlags().DurationVarP(&flagSpecMaxAge, "max-age", "", 0, "Reuse the cached StepLib spec without checking the StepLib for updates, if it was exported within the given duration (e.g. 1h).")
+	RootCmd.PersistentFlags().BoolVarP(&flagOffline, "offline", "", false, "Use the last cached StepLib spec without updating the StepLib (a local StepLib without a cached spec is read as it is). stepChanges reads only the cached GitHub releases and the existing step repository clones, no GitHub token is required.")
+	RootCmd.PersistentFlags().BoolVarP(&flagRefreshSpec, "refresh", "", false, "Ignore the cached StepLib specs and export the spec again.")
+}
+
+func steplibSource() (tools.SteplibSource, error) {
+	source, err := tools.NewSteplibSource(flagSteplib)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheDir, err := tools.DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return tools.NewCachedSteplibSource(source, tools.CacheOptions{
+		Dir:     cacheDir,
+		MaxAge:  flagSpecMaxAge,
+		Offline: flagOffline,
+		Refresh: flagRefreshSpec,
+	}), nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
@@ -12,26 +11,45 @@ import (
 	"unicode"
 
 	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/stepman/models"
 	"github.com/godrei/stepper/tools"
-	"github.com/google/go-github/github"
-	ver "github.com/hashicorp/go-version"
 	"github.com/spf13/cobra"
-	"golang.org/x/oauth2"
 )
 
 const (
-	defaultSteplibURI     = "https://github.com/bitrise-io/bitrise-steplib.git"
 	lastReleaseTimeLayout = "2006-01-02"
 )
 
 var (
 	flagGithubAPIToken string
 	flagStartTime      string
+	flagEndTime        string
+	flagSince          string
+	flagSinceLastRun   bool
+	flagStateFile      string
+	flagReportFormat   string
+	flagReportTemplate string
+	flagReportOutput   string
+	flagCollapse       bool
+	flagConcurrency    int
+	flagReleaseRefresh bool
+	flagNotesFallback  bool
+	flagNotesProvider  string
+	flagReposDir       string
+
+	flagConventionalCommits   bool
+	flagCategorize            bool
+	flagCategoryRules         string
+	flagPullRequestLabels     bool
+	flagSkipDependencyUpdates bool
+	flagPreviousSpec          string
+	flagGithubAPIURLs         []string
+	flagGithubTokens          []string
 )
 
 var stepChangesCmd = &cobra.Command{
 	Use:   "stepChanges",
-	Short: "Collects step changes from the given time to now in markdown ready format.",
+	Short: "Collects step changes from the given time window and renders them as markdown, JSON, HTML, Slack message or a custom template.",
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := stepChanges(); err != nil {
 			log.Errorf(err.Error())
@@ -40,34 +58,6 @@ var stepChangesCmd = &cobra.Command{
 	},
 }
 
-func getRelease(ctx context.Context, client *github.Client, repoOwner, repoName, tag string) (*github.RepositoryRelease, error) {
-	release, response, err := client.Repositories.GetReleaseByTag(ctx, repoOwner, repoName, tag)
-	if response.StatusCode < http.StatusOK || response.StatusCode > http.StatusMultipleChoices {
-		return release, nil
-	}
-	return release, err
-}
-
-func findLatestVersion(versions []string) (string, error) {
-	var latestVersion *ver.Version
-	for _, version := range versions {
-		v, err := ver.NewVersion(version)
-		if err != nil {
-			return "", err
-		}
-
-		if latestVersion == nil || latestVersion.LessThan(v) {
-			latestVersion = v
-		}
-	}
-
-	if latestVersion == nil {
-		return "", errors.New("failed to find latest version")
-	}
-
-	return latestVersion.String(), nil
-}
-
 func lowerCharacterFirst(str string) string {
 	for i, v := range str {
 		return string(unicode.ToLower(v)) + str[i+1:]
@@ -85,9 +75,7 @@ func normalizeReleaseLine(line string) string {
 		return ""
 	}
 
-	trimmed = lowerCharacterFirst(trimmed)
-
-	return "- " + trimmed
+	return lowerCharacterFirst(trimmed)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -103,143 +91,506 @@ func stepChanges() error {
 		flagGithubAPIToken = os.Getenv("STEPPER_GITHUB_API_TOKEN")
 	}
 
-	if flagGithubAPIToken == "" {
-		return fmt.Errorf("api-token not defined")
+	notesProviderType := NotesProviderType(flagNotesProvider)
+	switch notesProviderType {
+	case NotesProviderGitHub:
+		hosts, err := stepChangesGitHubHosts()
+		if err != nil {
+			return err
+		}
+		// Offline the releases are read from the cache, so no token is needed.
+		if !flagOffline && !hasGitHubToken(hosts) {
+			return fmt.Errorf("api-token or github-token not defined")
+		}
+	case NotesProviderGit:
+	default:
+		return fmt.Errorf("invalid notes provider (%s), available: [github, git]", flagNotesProvider)
+	}
+
+	startTime, endTime, err := stepChangesTimeWindow(time.Now())
+	if err != nil {
+		return err
+	}
+
+	var categoryRules *CategoryRules
+	if flagCategorize {
+		rules := DefaultCategoryRules()
+		if flagCategoryRules != "" {
+			rules, err = ReadCategoryRules(flagCategoryRules)
+			if err != nil {
+				return err
+			}
+		}
+		categoryRules = &rules
 	}
 
-	if flagStartTime == "" {
-		return fmt.Errorf("start not defined")
+	renderer, err := newReportRenderer(flagReportFormat, flagReportTemplate, flagCollapse)
+	if err != nil {
+		return err
 	}
 
 	// Collect new & updated step repos
-	if err := tools.StepmanUpdate(defaultSteplibURI); err != nil {
+	source, err := steplibSource()
+	if err != nil {
 		return err
 	}
 
-	steplib, err := tools.StepmanExportSpec(defaultSteplibURI, tools.ExportTypesFull)
+	steplib, err := source.Spec(tools.ExportTypesFull)
 	if err != nil {
 		return err
 	}
 
-	lastCheckTime, err := time.Parse(lastReleaseTimeLayout, flagStartTime)
+	previousSteplib, err := previousSteplibSpec(source, startTime)
 	if err != nil {
 		return err
 	}
 
-	updatedSteps := map[string]map[string]string{}
+	newSteps, updatedSteps := collectReleasedSteps(steplib, startTime, endTime)
+
+	report := StepChangesReport{
+		Start:        startTime,
+		End:          endTime,
+		NewSteps:     []StepChangesEntry{},
+		UpdatedSteps: []StepChangesEntry{},
+		MissingNotes: []MissingReleaseNotes{},
+	}
+
+	report.DeprecatedSteps, report.RemovedSteps, err = collectStepLifecycleChanges(previousSteplib, steplib, startTime, endTime)
+	if err != nil {
+		return err
+	}
+
+	for _, stepID := range sortedKeys(newSteps) {
+		entry, err := newStepChangesEntry(steplib, stepID, newSteps[stepID])
+		if err != nil {
+			return err
+		}
+		entry = withNewStepDetails(entry, steplib.Steps[stepID].Versions[entry.LatestVersion])
+		report.NewSteps = append(report.NewSteps, entry)
+	}
+
+	// collect releases
+	requestByRelease := map[string]NotesRequest{}
+	var requests []NotesRequest
+	for _, stepID := range sortedKeys(updatedSteps) {
+		entry, err := newStepChangesEntry(steplib, stepID, updatedSteps[stepID])
+		if err != nil {
+			return err
+		}
+
+		for _, release := range entry.Releases {
+			previousVersion, err := previousStepVersion(steplib.Steps[stepID], release.Version)
+			if err != nil {
+				return err
+			}
+
+			request := NotesRequest{
+				RepoURL:         updatedSteps[stepID][release.Version],
+				Version:         release.Version,
+				PreviousVersion: previousVersion,
+			}
+			requestByRelease[stepID+"@"+release.Version] = request
+			if request.RepoURL == "" {
+				// The spec has no source for the version.
+				continue
+			}
+			requests = append(requests, request)
+		}
+
+		report.UpdatedSteps = append(report.UpdatedSteps, entry)
+	}
+
+	notesProvider, err := newNotesProvider(notesProviderType)
+	if err != nil {
+		return err
+	}
+	lookups := notesProvider.Notes(context.Background(), requests)
+
+	for _, entry := range report.UpdatedSteps {
+		for i, release := range entry.Releases {
+			lookup, ok := lookups[requestByRelease[entry.StepID+"@"+release.Version]]
+			if !ok {
+				lookup = ReleaseLookup{Status: ReleaseLookupFailed, Err: fmt.Errorf("no source repository")}
+			}
+			if lookup.Status != ReleaseLookupFound {
+				if lookup.Err != nil {
+					log.Warnf("Failed to fetch release notes of %s@%s: %s", entry.StepID, release.Version, lookup.Err)
+				}
+				report.MissingNotes = append(report.MissingNotes, MissingReleaseNotes{
+					StepID:  entry.StepID,
+					Version: release.Version,
+					Status:  lookup.Status,
+					Reason:  lookup.Reason(),
+				})
+				continue
+			}
+
+			entry.Releases[i].ReleaseURL = lookup.Release.HTMLURL
+			entry.Releases[i].NotesSource = lookup.Release.Source
+			for _, note := range strings.Split(lookup.Release.Body, "\n") {
+				normalized := normalizeReleaseLine(note)
+				if normalized == "" {
+					continue
+				}
+				if flagSkipDependencyUpdates && isDependencyBotNote(normalized) {
+					continue
+				}
+				entry.Releases[i].Notes = append(entry.Releases[i].Notes, normalized)
+			}
+
+			if categoryRules != nil {
+				var labelsByNote map[string][]string
+				if labeler, ok := notesProvider.(PullRequestLabeler); ok && flagPullRequestLabels {
+					labelsByNote = labeler.PullRequestLabels(context.Background(), requestByRelease[entry.StepID+"@"+release.Version].RepoURL, entry.Releases[i].Notes)
+				}
+				entry.Releases[i].Sections = categorizeNotes(*categoryRules, entry.Releases[i].Notes, labelsByNote)
+			}
+		}
+	}
+
+	// print report
+	out, err := renderer.Render(report)
+	if err != nil {
+		return err
+	}
+
+	if flagReportOutput != "" {
+		if err := os.WriteFile(flagReportOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(out)
+	}
+
+	if flagSinceLastRun {
+		statePth, err := stepChangesStateFilePath()
+		if err != nil {
+			return err
+		}
+		if err := writeLastRunState(statePth, lastRunState{LastRunEnd: endTime}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newStepChangesEntry creates the report entry of a step from its released versions and their source URLs.
+func newStepChangesEntry(steplib models.StepCollectionModel, stepID string, stepVersionURLMap map[string]string) (StepChangesEntry, error) {
+	versions := []string{}
+	for version := range stepVersionURLMap {
+		versions = append(versions, version)
+	}
+
+	versions, err := tools.SortVersionsDesc(versions)
+	if err != nil {
+		return StepChangesEntry{}, err
+	}
+
+	entry := StepChangesEntry{
+		StepID:        stepID,
+		LatestVersion: versions[0],
+		SourceURL:     stepVersionURLMap[versions[0]],
+	}
+
+	for _, version := range versions {
+		step := steplib.Steps[stepID].Versions[version]
+		entry.Releases = append(entry.Releases, StepRelease{
+			Version:     version,
+			PublishedAt: step.PublishedAt,
+			Notes:       []string{},
+		})
+	}
+
+	return entry, nil
+}
+
+// collectReleasedSteps returns the source URL of the step versions published in the time window by step ID and version,
+// split into new and updated steps.
+func collectReleasedSteps(steplib models.StepCollectionModel, startTime, endTime time.Time) (map[string]map[string]string, map[string]map[string]string) {
 	newSteps := map[string]map[string]string{}
+	updatedSteps := map[string]map[string]string{}
 
 	for stepID, stepGroup := range steplib.Steps {
-		isFirstVersion := len(stepGroup.Versions) == 1
+		// A step is new if none of its versions was published before the time window,
+		// so a step first published and then patched in the window is still new.
+		isNewStep := !hasVersionPublishedBefore(stepGroup, startTime)
 
 		for version, step := range stepGroup.Versions {
-			if step.PublishedAt.After(lastCheckTime) {
-				if isFirstVersion {
-					stepVersionURLMap, ok := newSteps[stepID]
-					if !ok {
-						stepVersionURLMap = map[string]string{}
-					}
-
-					stepVersionURLMap[version] = strings.TrimSuffix(step.Source.Git, ".git")
-					newSteps[stepID] = stepVersionURLMap
-				} else {
-					stepVersionURLMap, ok := updatedSteps[stepID]
-					if !ok {
-						stepVersionURLMap = map[string]string{}
-					}
-
-					stepVersionURLMap[version] = strings.TrimSuffix(step.Source.Git, ".git")
-					updatedSteps[stepID] = stepVersionURLMap
+			if step.PublishedAt != nil && step.PublishedAt.After(startTime) && !step.PublishedAt.After(endTime) {
+				steps := updatedSteps
+				if isNewStep {
+					steps = newSteps
+				}
+
+				stepVersionURLMap, ok := steps[stepID]
+				if !ok {
+					stepVersionURLMap = map[string]string{}
 				}
+
+				stepVersionURLMap[version] = stepSourceURL(step)
+				steps[stepID] = stepVersionURLMap
 			}
 		}
 	}
-	//
 
-	// print release
-	backgroundContext := context.Background()
-	tokenSource := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: flagGithubAPIToken},
-	)
-	oauthClient := oauth2.NewClient(backgroundContext, tokenSource)
-	client := github.NewClient(oauthClient)
+	return newSteps, updatedSteps
+}
+
+// hasVersionPublishedBefore reports whether the step has a version published before the given time,
+// the versions without publish date are considered to be published before.
+func hasVersionPublishedBefore(stepGroup models.StepGroupModel, t time.Time) bool {
+	for _, step := range stepGroup.Versions {
+		if step.PublishedAt == nil || !step.PublishedAt.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// withNewStepDetails adds the description, project types and source link of the step's latest version to the new step entry.
+func withNewStepDetails(entry StepChangesEntry, step models.StepModel) StepChangesEntry {
+	entry.Title = tools.StringValue(step.Title)
+	entry.Summary = strings.TrimSpace(tools.StringValue(step.Summary))
+	entry.Description = strings.TrimSpace(tools.StringValue(step.Description))
+	entry.ProjectTypes = step.ProjectTypeTags
+	entry.SourceCodeURL = tools.StringValue(step.SourceCodeURL)
+	if entry.SourceCodeURL == "" {
+		entry.SourceCodeURL = entry.SourceURL
+	}
+	return entry
+}
 
-	fmt.Println()
-	fmt.Printf("## New steps\n")
-	fmt.Println()
+// stepChangesGitHubHosts creates the GitHub host configs from the flags and the env vars.
+func stepChangesGitHubHosts() (map[string]GitHubHost, error) {
+	apiURLs := flagGithubAPIURLs
+	if len(apiURLs) == 0 {
+		apiURLs = splitEnvList(os.Getenv("STEPPER_GITHUB_API_URL"))
+	}
 
-	newStepIDs := []string{}
-	for stepID := range newSteps {
-		newStepIDs = append(newStepIDs, stepID)
+	var tokens []string
+	if flagGithubAPIToken != "" {
+		tokens = append(tokens, defaultGitHubHost+"="+flagGithubAPIToken)
+	}
+	if len(flagGithubTokens) > 0 {
+		tokens = append(tokens, flagGithubTokens...)
+	} else {
+		tokens = append(tokens, splitEnvList(os.Getenv("STEPPER_GITHUB_TOKENS"))...)
 	}
-	sort.Strings(newStepIDs)
 
-	for _, stepID := range newStepIDs {
-		stepVersionURLMap := newSteps[stepID]
+	return parseGitHubHosts(apiURLs, tokens)
+}
+
+func hasGitHubToken(hosts map[string]GitHubHost) bool {
+	for _, host := range hosts {
+		if host.Token != "" {
+			return true
+		}
+	}
+	return false
+}
 
-		for version := range stepVersionURLMap {
-			fmt.Printf("- __%s %s__\n", stepID, version)
+// previousSteplibSpec returns the StepLib spec at the start of the window,
+// nil if the StepLib source has no history and the previous spec is not defined.
+func previousSteplibSpec(source tools.SteplibSource, start time.Time) (*models.StepCollectionModel, error) {
+	if flagPreviousSpec != "" {
+		spec, err := tools.SpecFileSource{Path: flagPreviousSpec}.Spec(tools.ExportTypesFull)
+		if err != nil {
+			return nil, err
+		}
+		return &spec, nil
+	}
+
+	historicalSource, ok := source.(interface {
+		SpecAt(t time.Time, exportType tools.ExportTypes) (models.StepCollectionModel, error)
+	})
+	if !ok {
+		log.Warnf("The StepLib has no history, deprecated steps are not reported, define the previous-spec flag")
+		return nil, nil
+	}
+
+	spec, err := historicalSource.SpecAt(start, tools.ExportTypesFull)
+	if err != nil {
+		if errors.Is(err, tools.ErrNoHistory) {
+			log.Warnf("%s, deprecated steps are not reported, define the previous-spec flag", err)
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &spec, nil
+}
+
+func newNotesProvider(providerType NotesProviderType) (NotesProvider, error) {
+	if providerType == NotesProviderGit {
+		reposDir := flagReposDir
+		if reposDir == "" {
+			var err error
+			reposDir, err = DefaultReposDir()
+			if err != nil {
+				return nil, err
+			}
 		}
+
+		return GitNotesProvider{
+			ReposDir:            reposDir,
+			Offline:             flagOffline,
+			ConventionalCommits: flagConventionalCommits,
+			Concurrency:         flagConcurrency,
+		}, nil
+	}
+
+	hosts, err := stepChangesGitHubHosts()
+	if err != nil {
+		return nil, err
+	}
+	clients, err := newGitHubClients(hosts)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println()
-	fmt.Println("---")
-	fmt.Println()
+	releaseCacheDir, err := DefaultReleaseCacheDir()
+	if err != nil {
+		return nil, err
+	}
 
-	fmt.Printf("## Step updates\n")
-	fmt.Println()
+	return NewReleaseFetcher(clients, ReleaseCache{Dir: releaseCacheDir, Refresh: flagReleaseRefresh, Offline: flagOffline}, flagConcurrency, flagNotesFallback), nil
+}
 
-	stepIDs := []string{}
-	for stepID := range updatedSteps {
-		stepIDs = append(stepIDs, stepID)
+// previousStepVersion returns the version released before the given version of the step,
+// an empty version means the given version is the first one.
+func previousStepVersion(stepGroup models.StepGroupModel, version string) (string, error) {
+	var versions []string
+	for v := range stepGroup.Versions {
+		versions = append(versions, v)
 	}
-	sort.Strings(stepIDs)
 
-	for _, stepID := range stepIDs {
-		stepVersionURLMap := updatedSteps[stepID]
+	versions, err := tools.SortVersionsDesc(versions)
+	if err != nil {
+		return "", err
+	}
 
-		versions := []string{}
-		for version := range stepVersionURLMap {
-			versions = append(versions, version)
+	for i, v := range versions {
+		if v == version && i+1 < len(versions) {
+			return versions[i+1], nil
 		}
+	}
+	return "", nil
+}
+
+// releaseKeyFromURL creates the key of the release from the step's GitHub repository URL and the released version.
+func releaseKeyFromURL(repoURL, version string) (ReleaseKey, error) {
+	u, err := parseRepoURL(repoURL)
+	if err != nil {
+		return ReleaseKey{}, fmt.Errorf("invalid step url: %s", repoURL)
+	}
+
+	split := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || len(split) < 2 {
+		return ReleaseKey{}, fmt.Errorf("invalid step url: %s", repoURL)
+	}
+	return ReleaseKey{Host: strings.ToLower(u.Host), Owner: split[len(split)-2], Repo: split[len(split)-1], Tag: version}, nil
+}
+
+func sortedKeys(m map[string]map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-		newVersion, err := findLatestVersion(versions)
+// stepChangesTimeWindow returns the (start, end] time window of the report.
+func stepChangesTimeWindow(now time.Time) (time.Time, time.Time, error) {
+	endTime := now
+	if flagEndTime != "" {
+		var err error
+		endTime, err = parseEndTimeFlag(flagEndTime)
 		if err != nil {
-			return err
+			return time.Time{}, time.Time{}, err
 		}
+	}
 
-		fmt.Printf("- __%s %s:__\n", stepID, newVersion)
+	if flagStartTime != "" && flagSince != "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("only one of start and since can be defined")
+	}
 
-		for version, url := range stepVersionURLMap {
-			split := strings.Split(url, "/")
-			if len(split) < 2 {
-				return fmt.Errorf("invalid step url: %s", url)
-			}
-			name := split[len(split)-1]
-			owner := split[len(split)-2]
+	if flagSinceLastRun {
+		statePth, err := stepChangesStateFilePath()
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
 
-			release, err := getRelease(backgroundContext, client, owner, name, version)
-			if err != nil {
-				return err
+		state, found, err := readLastRunState(statePth)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if found {
+			if !state.LastRunEnd.Before(endTime) {
+				return time.Time{}, time.Time{}, fmt.Errorf("last run end (%s) is not before end (%s)", state.LastRunEnd.Format(time.RFC3339), endTime.Format(time.RFC3339))
 			}
+			return state.LastRunEnd, endTime, nil
+		}
+		// The first run falls back to the start or since flag.
+	}
 
-			if release != nil && release.Body != nil {
-				split := strings.Split(*release.Body, "\n")
-				for _, note := range split {
-					normalized := normalizeReleaseLine(note)
-					if normalized != "" {
-						fmt.Printf("  %s\n", normalized)
-					}
-				}
-			}
+	var startTime time.Time
+	switch {
+	case flagStartTime != "":
+		var err error
+		startTime, err = parseTimeFlag(flagStartTime)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
 		}
+	case flagSince != "":
+		duration, err := parseRelativeDuration(flagSince)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		startTime = endTime.Add(-duration)
+	case flagSinceLastRun:
+		return time.Time{}, time.Time{}, fmt.Errorf("no previous run found in the state file, define the start or since flag for the first run")
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("start not defined, define one of: start, since, since-last-run")
 	}
 
-	return nil
+	if !startTime.Before(endTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start (%s) is not before end (%s)", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+	}
+
+	return startTime, endTime, nil
+}
+
+func stepChangesStateFilePath() (string, error) {
+	if flagStateFile != "" {
+		return flagStateFile, nil
+	}
+	return defaultStateFilePath("stepChanges")
 }
 
 func init() {
 	RootCmd.AddCommand(stepChangesCmd)
-	stepChangesCmd.Flags().StringVarP(&flagGithubAPIToken, "api-token", "", "", "Github API Access token. Define this flag or set STEPPER_GITHUB_API_TOKEN env.")
-	stepChangesCmd.Flags().StringVarP(&flagStartTime, "start", "", "", "From which time should collect the step changes? Format: 2006-01-02.")
+	stepChangesCmd.Flags().StringVarP(&flagGithubAPIToken, "api-token", "", "", "Github API Access token of github.com. Define this flag or set STEPPER_GITHUB_API_TOKEN env.")
+	stepChangesCmd.Flags().StringArrayVarP(&flagGithubAPIURLs, "github-api-url", "", nil, "GitHub API base URL, as 'https://api.example.com/' for the github.com steps or as '<host>=<API URL>' for the steps hosted on a GitHub Enterprise host. Can be repeated. Define this flag or set STEPPER_GITHUB_API_URL env (comma separated). Default for the Enterprise hosts: https://<host>/api/v3/.")
+	stepChangesCmd.Flags().StringArrayVarP(&flagGithubTokens, "github-token", "", nil, "API token of a GitHub Enterprise host as '<host>=<token>'. Can be repeated. Define this flag or set STEPPER_GITHUB_TOKENS env (comma separated).")
+	stepChangesCmd.Flags().StringVarP(&flagStartTime, "start", "", "", "From which time should collect the step changes? Format: 2006-01-02 or RFC3339.")
+	stepChangesCmd.Flags().StringVarP(&flagEndTime, "end", "", "", "Until which time should collect the step changes? Format: 2006-01-02 (until the end of the day, the day included) or RFC3339. Default: now.")
+	stepChangesCmd.Flags().StringVarP(&flagSince, "since", "", "", "Collect the step changes of the given duration before the end time, e.g. 2w, 10d or 12h.")
+	stepChangesCmd.Flags().BoolVarP(&flagSinceLastRun, "since-last-run", "", false, "Collect the step changes since the end of the last successful '--since-last-run' report, the end time is stored in the state file. The first run uses the start or since flag.")
+	stepChangesCmd.Flags().StringVarP(&flagReportFormat, "format", "", string(ReportFormatMarkdown), "Report format [markdown,json,html,slack,template].")
+	stepChangesCmd.Flags().StringVarP(&flagReportTemplate, "template", "", "", "Path to a Go template file used by the template format. The template is executed on the report.")
+	stepChangesCmd.Flags().BoolVarP(&flagCollapse, "collapse", "", false, "Merge the release notes of every released version of a step instead of grouping them by version.")
+	stepChangesCmd.Flags().IntVarP(&flagConcurrency, "concurrency", "", 8, "Number of release notes (GitHub releases or step repositories) to fetch concurrently.")
+	stepChangesCmd.Flags().BoolVarP(&flagReleaseRefresh, "refresh-releases", "", false, "Ignore the cached GitHub releases and fetch them again. The StepLib spec cache is controlled by the --refresh flag.")
+	stepChangesCmd.Flags().StringVarP(&flagNotesProvider, "notes-provider", "", string(NotesProviderGitHub), "Source of the release notes [github,git]. The git provider builds the notes from the commit messages of the step repositories and requires no API token.")
+	stepChangesCmd.Flags().StringVarP(&flagReposDir, "repos-dir", "", "", "Dir of the step repository clones used by the git notes provider. Default: <user cache dir>/stepper/step-repos.")
+	stepChangesCmd.Flags().BoolVarP(&flagConventionalCommits, "conventional-commits", "", false, "Keep only the feat, fix, perf, revert and breaking change conventional commits in the notes of the git notes provider.")
+	stepChangesCmd.Flags().BoolVarP(&flagCategorize, "categorize", "", false, "Group the release notes into Breaking changes, Features, Fixes, Dependencies and Other sections.")
+	stepChangesCmd.Flags().StringVarP(&flagCategoryRules, "category-rules", "", "", "Path to a yaml file with the keyword and pull request label rules of the categories, the defined categories replace the default rules.")
+	stepChangesCmd.Flags().BoolVarP(&flagPullRequestLabels, "pr-labels", "", false, "Categorise the notes by the labels of the referenced pull requests too (github notes provider only, not fetched with --offline).")
+	stepChangesCmd.Flags().BoolVarP(&flagSkipDependencyUpdates, "skip-dependency-updates", "", false, "Leave out the Renovate and Dependabot dependency update notes.")
+	stepChangesCmd.Flags().BoolVarP(&flagNotesFallback, "notes-fallback", "", true, "Use the message of the annotated tag or the version's section of the CHANGELOG.md, if the version has no GitHub release.")
+	stepChangesCmd.Flags().StringVarP(&flagReportOutput, "output", "", "", "Write the report to the given file instead of the standard output.")
+	stepChangesCmd.Flags().StringVarP(&flagPreviousSpec, "previous-spec", "", "", "Path to the spec.json of the StepLib at the start of the window, used to find the deprecated and removed steps. Default: the spec of the StepLib's git history at the start.")
+	stepChangesCmd.Flags().StringVarP(&flagStateFile, "state-file", "", "", "Path of the state file used by '--since-last-run'. Default: <user config dir>/stepper/stepChanges-state.json.")
 }
@@ -0,0 +1,594 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmlTemplate "html/template"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ReportFormat ...
+type ReportFormat string
+
+const (
+	// ReportFormatMarkdown ...
+	ReportFormatMarkdown ReportFormat = "markdown"
+	// ReportFormatJSON ...
+	ReportFormatJSON ReportFormat = "json"
+	// ReportFormatHTML ...
+	ReportFormatHTML ReportFormat = "html"
+	// ReportFormatSlack renders a Slack Block Kit message.
+	ReportFormatSlack ReportFormat = "slack"
+	// ReportFormatTemplate renders a user-supplied Go template.
+	ReportFormatTemplate ReportFormat = "template"
+)
+
+// StepChangesReport is the model of the step changes published in a time window.
+type StepChangesReport struct {
+	Start           time.Time            `json:"start"`
+	End             time.Time            `json:"end"`
+	NewSteps        []StepChangesEntry   `json:"new_steps"`
+	UpdatedSteps    []StepChangesEntry   `json:"updated_steps"`
+	DeprecatedSteps []StepLifecycleEntry `json:"deprecated_steps"`
+	RemovedSteps    []StepLifecycleEntry `json:"removed_steps"`
+	// MissingNotes lists the updated step versions without release notes.
+	MissingNotes []MissingReleaseNotes `json:"missing_notes"`
+}
+
+// MissingReleaseNotes is an updated step version, whose release notes could not be found.
+type MissingReleaseNotes struct {
+	StepID  string              `json:"step_id"`
+	Version string              `json:"version"`
+	Status  ReleaseLookupStatus `json:"status"`
+	Reason  string              `json:"reason"`
+}
+
+// StepChangesEntry is a new or updated step of the report.
+type StepChangesEntry struct {
+	StepID        string `json:"step_id"`
+	LatestVersion string `json:"latest_version"`
+	SourceURL     string `json:"source_url"`
+	// Releases lists the versions published in the time window in descending semver order.
+	Releases []StepRelease `json:"releases"`
+
+	// Title, Summary, Description, ProjectTypes and SourceCodeURL describe the new steps,
+	// they are taken from the spec of the step's latest version.
+	Title        string   `json:"title,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	ProjectTypes []string `json:"project_types,omitempty"`
+	// SourceCodeURL is the step's source_code_url, or the source git URL if the step does not define it.
+	SourceCodeURL string `json:"source_code_url,omitempty"`
+}
+
+// Overview returns the summary of the step, or the first paragraph of its description, on a single line.
+func (e StepChangesEntry) Overview() string {
+	overview := e.Summary
+	if overview == "" {
+		overview, _, _ = strings.Cut(e.Description, "\n\n")
+	}
+	return strings.Join(strings.Fields(overview), " ")
+}
+
+// ReleaseTitles returns the titles of the releases, like '1.0.1 (2024-03-05), 1.0.0 (2024-03-01)'.
+func (e StepChangesEntry) ReleaseTitles() string {
+	var titles []string
+	for _, release := range e.Releases {
+		titles = append(titles, release.Title())
+	}
+	return strings.Join(titles, ", ")
+}
+
+// StepRelease is a step version published in the time window.
+type StepRelease struct {
+	Version     string     `json:"version"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	// ReleaseURL is the link of the GitHub release, empty if the release was not found.
+	ReleaseURL  string             `json:"release_url,omitempty"`
+	NotesSource ReleaseNotesSource `json:"notes_source,omitempty"`
+	Notes       []string           `json:"notes"`
+	// Sections groups the notes by category, empty if the notes are not categorised.
+	Sections []NoteSection `json:"sections,omitempty"`
+}
+
+// Title returns the version with its release date, like '2.1.0 (2024-03-01)'.
+func (r StepRelease) Title() string {
+	if r.PublishedAt == nil {
+		return r.Version
+	}
+	return fmt.Sprintf("%s (%s)", r.Version, formatReportTime(r.PublishedAt))
+}
+
+// Notes returns the release notes of every release of the step.
+func (e StepChangesEntry) Notes() []string {
+	var notes []string
+	for _, release := range e.Releases {
+		notes = append(notes, release.Notes...)
+	}
+	return notes
+}
+
+// Sections returns the categorised notes of every release of the step.
+func (e StepChangesEntry) Sections() []NoteSection {
+	var sectionLists [][]NoteSection
+	for _, release := range e.Releases {
+		sectionLists = append(sectionLists, release.Sections)
+	}
+	return mergeNoteSections(sectionLists...)
+}
+
+// ReportRenderer renders a step changes report.
+type ReportRenderer interface {
+	Render(report StepChangesReport) (string, error)
+}
+
+// newReportRenderer creates the renderer of the given format.
+// The markdown, HTML and Slack renderers group the release notes by version, unless collapse is set,
+// which merges the notes of every released version under the step.
+func newReportRenderer(format, templatePth string, collapse bool) (ReportRenderer, error) {
+	if templatePth != "" && ReportFormat(format) != ReportFormatTemplate {
+		return nil, fmt.Errorf("template can only be used with the template format")
+	}
+
+	switch ReportFormat(format) {
+	case ReportFormatMarkdown:
+		return MarkdownReportRenderer{Collapse: collapse}, nil
+	case ReportFormatJSON:
+		return JSONReportRenderer{}, nil
+	case ReportFormatHTML:
+		return HTMLReportRenderer{Collapse: collapse}, nil
+	case ReportFormatSlack:
+		return SlackReportRenderer{Collapse: collapse}, nil
+	case ReportFormatTemplate:
+		if templatePth == "" {
+			return nil, fmt.Errorf("template not defined, the template format requires a template file")
+		}
+		content, err := os.ReadFile(templatePth)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New("report").Funcs(reportTemplateFuncs).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return TemplateReportRenderer{Template: tmpl}, nil
+	default:
+		return nil, fmt.Errorf("invalid format (%s), available: [markdown, json, html, slack, template]", format)
+	}
+}
+
+var reportTemplateFuncs = map[string]interface{}{
+	"join":       strings.Join,
+	"formatTime": formatReportTime,
+}
+
+func formatReportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(lastReleaseTimeLayout)
+}
+
+// MarkdownReportRenderer ...
+type MarkdownReportRenderer struct {
+	Collapse bool
+}
+
+// Render ...
+func (r MarkdownReportRenderer) Render(report StepChangesReport) (string, error) {
+	var lines []string
+
+	lines = append(lines, "", "## New steps", "")
+	for _, entry := range report.NewSteps {
+		line := fmt.Sprintf("- __%s %s__", entry.StepID, entry.LatestVersion)
+		if overview := entry.Overview(); overview != "" {
+			line += ": " + overview
+		}
+		lines = append(lines, line)
+
+		if len(entry.ProjectTypes) > 0 {
+			lines = append(lines, "  - Project types: "+strings.Join(entry.ProjectTypes, ", "))
+		}
+		if entry.SourceCodeURL != "" {
+			lines = append(lines, "  - Source: "+entry.SourceCodeURL)
+		}
+		lines = append(lines, "  - Releases: "+entry.ReleaseTitles())
+	}
+
+	lines = append(lines, "", "---", "", "## Step updates", "")
+	for _, entry := range report.UpdatedSteps {
+		lines = append(lines, fmt.Sprintf("- __%s %s:__", entry.StepID, entry.LatestVersion))
+
+		if r.Collapse {
+			lines = append(lines, markdownNoteLines(entry.Notes(), entry.Sections(), "  ")...)
+			continue
+		}
+
+		for _, release := range entry.Releases {
+			title := release.Title()
+			if release.ReleaseURL != "" {
+				title = fmt.Sprintf("[%s](%s)", title, release.ReleaseURL)
+			}
+			lines = append(lines, "  - "+title)
+			lines = append(lines, markdownNoteLines(release.Notes, release.Sections, "    ")...)
+		}
+	}
+
+	if len(report.DeprecatedSteps) > 0 {
+		lines = append(lines, "", "---", "", "## Deprecated steps", "")
+		lines = append(lines, markdownLifecycleLines(report.DeprecatedSteps)...)
+	}
+
+	if len(report.RemovedSteps) > 0 {
+		lines = append(lines, "", "---", "", "## Removed steps", "")
+		lines = append(lines, markdownLifecycleLines(report.RemovedSteps)...)
+	}
+
+	if len(report.MissingNotes) > 0 {
+		lines = append(lines, "", "---", "", "## No release notes", "")
+		for _, missing := range report.MissingNotes {
+			lines = append(lines, fmt.Sprintf("- __%s %s__: %s", missing.StepID, missing.Version, missing.Reason))
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func markdownLifecycleLines(entries []StepLifecycleEntry) []string {
+	var lines []string
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("- __%s %s__%s", entry.StepID, entry.LatestVersion, entry.Details()))
+	}
+	return lines
+}
+
+// markdownNoteLines lists the notes under the category headers, if the notes are categorised.
+func markdownNoteLines(notes []string, sections []NoteSection, indent string) []string {
+	var lines []string
+	if len(sections) == 0 {
+		for _, note := range notes {
+			lines = append(lines, indent+"- "+note)
+		}
+		return lines
+	}
+
+	for _, section := range sections {
+		lines = append(lines, indent+"- _"+section.Title+"_")
+		for _, note := range section.Notes {
+			lines = append(lines, indent+"  - "+note)
+		}
+	}
+	return lines
+}
+
+// JSONReportRenderer ...
+type JSONReportRenderer struct{}
+
+// Render ...
+func (r JSONReportRenderer) Render(report StepChangesReport) (string, error) {
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+const htmlReportTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Step changes {{.Start.Format "2006-01-02"}} - {{.End.Format "2006-01-02"}}</title>
+</head>
+<body>
+<h2>New steps</h2>
+<ul>
+{{- range .NewSteps}}
+<li><a href="{{.SourceCodeURL}}"><b>{{.StepID}} {{.LatestVersion}}</b></a>{{with .Overview}}: {{.}}{{end}}
+<ul>
+{{- with .ProjectTypes}}
+<li>Project types: {{join . ", "}}</li>
+{{- end}}
+<li>Releases: {{.ReleaseTitles}}</li>
+</ul>
+</li>
+{{- end}}
+</ul>
+<h2>Step updates</h2>
+<ul>
+{{- range .UpdatedSteps}}
+<li><a href="{{.SourceURL}}"><b>{{.StepID}} {{.LatestVersion}}</b></a>
+{{- if $.Collapse}}
+{{- template "notes" (notes .Notes .Sections)}}
+{{- else}}
+<ul>
+{{- range .Releases}}
+<li>{{if .ReleaseURL}}<a href="{{.ReleaseURL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}
+{{- template "notes" (notes .Notes .Sections)}}
+</li>
+{{- end}}
+</ul>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+{{- with .DeprecatedSteps}}
+<h2>Deprecated steps</h2>
+{{- template "lifecycle" .}}
+{{- end}}
+{{- with .RemovedSteps}}
+<h2>Removed steps</h2>
+{{- template "lifecycle" .}}
+{{- end}}
+{{- with .MissingNotes}}
+<h2>No release notes</h2>
+<ul>
+{{- range .}}
+<li><b>{{.StepID}} {{.Version}}</b>: {{.Reason}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+{{- define "lifecycle"}}
+<ul>
+{{- range .}}
+<li><b>{{.StepID}} {{.LatestVersion}}</b>{{.Details}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- define "notes"}}
+{{- if .Sections}}
+<ul>
+{{- range .Sections}}
+<li><i>{{.Title}}</i>
+<ul>
+{{- range .Notes}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</li>
+{{- end}}
+</ul>
+{{- else if .Notes}}
+<ul>
+{{- range .Notes}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}`
+
+// HTMLReportRenderer ...
+type HTMLReportRenderer struct {
+	Collapse bool
+}
+
+// Render ...
+func (r HTMLReportRenderer) Render(report StepChangesReport) (string, error) {
+	funcs := htmlTemplate.FuncMap{
+		"join": strings.Join,
+		"notes": func(notes []string, sections []NoteSection) interface{} {
+			return struct {
+				Notes    []string
+				Sections []NoteSection
+			}{Notes: notes, Sections: sections}
+		},
+	}
+	tmpl, err := htmlTemplate.New("report").Funcs(funcs).Parse(htmlReportTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	data := struct {
+		StepChangesReport
+		Collapse bool
+	}{
+		StepChangesReport: report,
+		Collapse:          r.Collapse,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SlackReportRenderer renders the report as Slack Block Kit message payloads.
+//
+// The output is a JSON array of messages: Slack accepts at most 50 blocks per message,
+// so a long report is split into several messages, and the section texts are truncated at 3000 characters.
+type SlackReportRenderer struct {
+	Collapse bool
+}
+
+const (
+	slackMaxBlocksPerMessage  = 50
+	slackMaxSectionTextLength = 3000
+)
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackMessage struct {
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// Render ...
+func (r SlackReportRenderer) Render(report StepChangesReport) (string, error) {
+	header := func(text string) slackBlock {
+		return slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: text}}
+	}
+	section := func(text string) slackBlock {
+		return slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncateSlackText(text, slackMaxSectionTextLength)}}
+	}
+
+	var blocks []slackBlock
+
+	blocks = append(blocks, header("New steps"))
+	if len(report.NewSteps) == 0 {
+		blocks = append(blocks, section("_No new steps._"))
+	}
+	for _, entry := range report.NewSteps {
+		lines := []string{slackStepTitle(entry.SourceCodeURL, entry.StepID, entry.LatestVersion)}
+		if overview := entry.Overview(); overview != "" {
+			lines = append(lines, slackEscape(overview))
+		}
+		if len(entry.ProjectTypes) > 0 {
+			lines = append(lines, "_Project types:_ "+slackEscape(strings.Join(entry.ProjectTypes, ", ")))
+		}
+		lines = append(lines, "_Releases:_ "+slackEscape(entry.ReleaseTitles()))
+		blocks = append(blocks, section(strings.Join(lines, "\n")))
+	}
+
+	blocks = append(blocks, slackBlock{Type: "divider"}, header("Step updates"))
+	if len(report.UpdatedSteps) == 0 {
+		blocks = append(blocks, section("_No step updates._"))
+	}
+	for _, entry := range report.UpdatedSteps {
+		lines := []string{slackStepTitle(entry.SourceURL, entry.StepID, entry.LatestVersion)}
+		if r.Collapse {
+			lines = append(lines, slackNoteLines(entry.Notes(), entry.Sections())...)
+		} else {
+			for _, release := range entry.Releases {
+				title := slackEscape(release.Title())
+				if release.ReleaseURL != "" {
+					title = fmt.Sprintf("<%s|%s>", release.ReleaseURL, title)
+				}
+				lines = append(lines, "_"+title+"_")
+				lines = append(lines, slackNoteLines(release.Notes, release.Sections)...)
+			}
+		}
+		blocks = append(blocks, section(strings.Join(lines, "\n")))
+	}
+
+	for _, lifecycle := range []struct {
+		title   string
+		entries []StepLifecycleEntry
+	}{
+		{title: "Deprecated steps", entries: report.DeprecatedSteps},
+		{title: "Removed steps", entries: report.RemovedSteps},
+	} {
+		if len(lifecycle.entries) == 0 {
+			continue
+		}
+
+		var lines []string
+		for _, entry := range lifecycle.entries {
+			lines = append(lines, fmt.Sprintf("• *%s %s*%s", slackEscape(entry.StepID), slackEscape(entry.LatestVersion), slackEscape(entry.Details())))
+		}
+		blocks = append(blocks, slackBlock{Type: "divider"}, header(lifecycle.title), section(strings.Join(lines, "\n")))
+	}
+
+	if len(report.MissingNotes) > 0 {
+		var lines []string
+		for _, missing := range report.MissingNotes {
+			lines = append(lines, fmt.Sprintf("• *%s %s*: %s", slackEscape(missing.StepID), slackEscape(missing.Version), slackEscape(missing.Reason)))
+		}
+		blocks = append(blocks, slackBlock{Type: "divider"}, header("No release notes"), section(strings.Join(lines, "\n")))
+	}
+
+	messages := []slackMessage{}
+	for start := 0; start < len(blocks); start += slackMaxBlocksPerMessage {
+		end := start + slackMaxBlocksPerMessage
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+		messages = append(messages, slackMessage{Blocks: blocks[start:end]})
+	}
+
+	out, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func slackStepTitle(url, stepID, version string) string {
+	title := slackEscape(stepID + " " + version)
+	if url == "" {
+		return "*" + title + "*"
+	}
+	return fmt.Sprintf("*<%s|%s>*", url, title)
+}
+
+func slackNoteLines(notes []string, sections []NoteSection) []string {
+	var lines []string
+	if len(sections) == 0 {
+		for _, note := range notes {
+			lines = append(lines, "• "+slackEscape(note))
+		}
+		return lines
+	}
+
+	for _, section := range sections {
+		lines = append(lines, "*"+slackEscape(section.Title)+"*")
+		for _, note := range section.Notes {
+			lines = append(lines, "• "+slackEscape(note))
+		}
+	}
+	return lines
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape escapes the control characters of the Slack mrkdwn text, so a note like '<!channel>' or 'a < b' is shown as it is.
+func slackEscape(text string) string {
+	return slackEscaper.Replace(text)
+}
+
+// truncateSlackText keeps the whole lines of the text, which fit in the given length, and marks the truncation with an ellipsis line.
+// A first line longer than the limit is cut before any link or escaped character, which would be cut in half.
+func truncateSlackText(text string, maxLength int) string {
+	const ellipsis = "\n…"
+	if len([]rune(text)) <= maxLength {
+		return text
+	}
+
+	var kept []string
+	length := 0
+	for _, line := range strings.Split(text, "\n") {
+		lineLength := len([]rune(line))
+		if length > 0 {
+			lineLength++ // newline
+		}
+		if length+lineLength > maxLength-len([]rune(ellipsis)) {
+			break
+		}
+		kept = append(kept, line)
+		length += lineLength
+	}
+	if len(kept) > 0 {
+		return strings.Join(kept, "\n") + ellipsis
+	}
+
+	cut := string([]rune(text)[:maxLength-1])
+	if i := strings.LastIndexAny(cut, "<&"); i != -1 && !strings.ContainsAny(cut[i:], ">;") {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// TemplateReportRenderer executes a user-supplied Go template on the report.
+type TemplateReportRenderer struct {
+	Template *template.Template
+}
+
+// Render ...
+func (r TemplateReportRenderer) Render(report StepChangesReport) (string, error) {
+	var buf bytes.Buffer
+	if err := r.Template.Execute(&buf, report); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSlackEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Notify <!channel>", want: "Notify &lt;!channel&gt;"},
+		{text: "a < b && b > c", want: "a &lt; b &amp;&amp; b &gt; c"},
+		{text: "*bold* _italic_", want: "*bold* _italic_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := slackEscape(tt.text); got != tt.want {
+				t.Errorf("slackEscape() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateSlackText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      string
+	}{
+		{name: "fits", text: "line 1\nline 2", maxLength: 13, want: "line 1\nline 2"},
+		{name: "whole lines kept", text: "line 1\nline 2\nline 3", maxLength: 14, want: "line 1\n…"},
+		{name: "multi-byte characters counted as one", text: "ünïcödé\nline 2", maxLength: 14, want: "ünïcödé\nline 2"},
+		{name: "long first line", text: "abcdefghij", maxLength: 5, want: "abcd…"},
+		{name: "long first line with link", text: "ab <https://example.com|x>", maxLength: 10, want: "ab …"},
+		{name: "long first line with escaped character", text: "abc &amp; d", maxLength: 7, want: "abc …"},
+		{name: "long first line with closed link", text: "<a|b> cdefgh", maxLength: 8, want: "<a|b> c…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateSlackText(tt.text, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("truncateSlackText() = %q, want %q", got, tt.want)
+			}
+			if length := len([]rune(got)); length > tt.maxLength {
+				t.Errorf("truncateSlackText() length = %d, want at most %d", length, tt.maxLength)
+			}
+		})
+	}
+}
+
+func TestSlackReportRenderer_Render(t *testing.T) {
+	tests := []struct {
+		name         string
+		updatedSteps int
+		noteLength   int
+		wantMessages int
+	}{
+		{name: "single message", updatedSteps: 3, noteLength: 10, wantMessages: 1},
+		{name: "split into messages", updatedSteps: 60, noteLength: 10, wantMessages: 2},
+		{name: "long notes", updatedSteps: 1, noteLength: 5000, wantMessages: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report StepChangesReport
+			for i := 0; i < tt.updatedSteps; i++ {
+				report.UpdatedSteps = append(report.UpdatedSteps, StepChangesEntry{
+					StepID:        fmt.Sprintf("step-%d", i),
+					LatestVersion: "1.0.0",
+					Releases: []StepRelease{
+						{Version: "1.0.0", Notes: []string{"<!channel> " + strings.Repeat("a", tt.noteLength)}},
+					},
+				})
+			}
+
+			out, err := SlackReportRenderer{}.Render(report)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			var messages []slackMessage
+			if err := json.Unmarshal([]byte(out), &messages); err != nil {
+				t.Fatalf("Render() output is not a JSON array of messages: %v", err)
+			}
+			if len(messages) != tt.wantMessages {
+				t.Errorf("Render() messages = %d, want %d", len(messages), tt.wantMessages)
+			}
+
+			for _, message := range messages {
+				if len(message.Blocks) > slackMaxBlocksPerMessage {
+					t.Errorf("Render() blocks = %d, want at most %d", len(message.Blocks), slackMaxBlocksPerMessage)
+				}
+				for _, block := range message.Blocks {
+					if block.Text == nil {
+						continue
+					}
+					if length := len([]rune(block.Text.Text)); length > slackMaxSectionTextLength {
+						t.Errorf("Render() section length = %d, want at most %d", length, slackMaxSectionTextLength)
+					}
+					if strings.Contains(block.Text.Text, "<!channel>") {
+						t.Errorf("Render() section is not escaped: %s", block.Text.Text)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/stepman/models"
+)
+
+func TestHasVersionPublishedBefore(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	published := func(t time.Time) models.StepModel {
+		return models.StepModel{PublishedAt: &t}
+	}
+
+	tests := []struct {
+		name     string
+		versions map[string]models.StepModel
+		want     bool
+	}{
+		{name: "versions on both sides of the start", versions: map[string]models.StepModel{"1.0.0": published(start.AddDate(0, 0, -10)), "1.1.0": published(start.AddDate(0, 0, 2))}, want: true},
+		{name: "version published at the start", versions: map[string]models.StepModel{"1.0.0": published(start)}, want: true},
+		{name: "version without publish date", versions: map[string]models.StepModel{"1.0.0": {}, "1.1.0": published(start.AddDate(0, 0, 2))}, want: true},
+		{name: "every version after the start", versions: map[string]models.StepModel{"1.0.0": published(start.Add(time.Second)), "1.0.1": published(start.AddDate(0, 0, 3))}, want: false},
+		{name: "no versions", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasVersionPublishedBefore(models.StepGroupModel{Versions: tt.versions}, start); got != tt.want {
+				t.Errorf("hasVersionPublishedBefore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectReleasedSteps(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	version := func(day int, source string) models.StepModel {
+		publishedAt := time.Date(2024, 3, day, 12, 0, 0, 0, time.UTC)
+		step := models.StepModel{PublishedAt: &publishedAt}
+		if source != "" {
+			step.Source = &models.StepSourceModel{Git: source}
+		}
+		return step
+	}
+
+	steplib := models.StepCollectionModel{
+		Steps: models.StepHash{
+			// Published before and patched in the window.
+			"git-clone": {Versions: map[string]models.StepModel{
+				"8.0.0": version(-5, "https://github.com/bitrise-steplib/steps-git-clone.git"),
+				"8.1.0": version(5, "https://github.com/bitrise-steplib/steps-git-clone.git"),
+			}},
+			// First published and then patched in the window.
+			"new-step": {Versions: map[string]models.StepModel{
+				"1.0.0": version(2, "https://github.com/bitrise-steplib/steps-new-step.git"),
+				"1.0.1": version(10, "https://github.com/bitrise-steplib/steps-new-step.git"),
+			}},
+			// First published in the window and patched after it.
+			"newer-step": {Versions: map[string]models.StepModel{
+				"1.0.0": version(14, ""),
+				"1.0.1": version(20, ""),
+			}},
+			// Not released in the window.
+			"script": {Versions: map[string]models.StepModel{
+				"1.1.5": version(-20, "https://github.com/bitrise-steplib/steps-script.git"),
+			}},
+		},
+	}
+
+	wantNew := map[string]map[string]string{
+		"new-step": {
+			"1.0.0": "https://github.com/bitrise-steplib/steps-new-step",
+			"1.0.1": "https://github.com/bitrise-steplib/steps-new-step",
+		},
+		"newer-step": {"1.0.0": ""},
+	}
+	wantUpdated := map[string]map[string]string{
+		"git-clone": {"8.1.0": "https://github.com/bitrise-steplib/steps-git-clone"},
+	}
+
+	newSteps, updatedSteps := collectReleasedSteps(steplib, start, end)
+	if !reflect.DeepEqual(newSteps, wantNew) {
+		t.Errorf("collectReleasedSteps() new = %v, want %v", newSteps, wantNew)
+	}
+	if !reflect.DeepEqual(updatedSteps, wantUpdated) {
+		t.Errorf("collectReleasedSteps() updated = %v, want %v", updatedSteps, wantUpdated)
+	}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	stepIDConstSuffix      = "ID"
+	stepVersionConstSuffix = "Version"
+)
+
+// stepConst is a string constant of the steps/const.go file, like GitCloneVersion = "8.0.0".
+type stepConst struct {
+	Name  string
+	Value string
+	// start and end are the byte offsets of the constant's string literal in the file.
+	start, end int
+}
+
+// stepConstPair is the ID and the version constant of a step, like GitCloneID and GitCloneVersion.
+type stepConstPair struct {
+	ID      stepConst
+	Version stepConst
+}
+
+// stepConsts are the step constants of a steps/const.go file.
+type stepConsts struct {
+	// Pairs lists the steps in the order of their ID constants.
+	Pairs []stepConstPair
+	// OrphanIDs are the XxxID constants without XxxVersion constant.
+	OrphanIDs []stepConst
+	// OrphanVersions are the XxxVersion constants without XxxID constant.
+	OrphanVersions []stepConst
+}
+
+// parseStepConsts parses the string constants of the file and pairs the XxxID and XxxVersion constants by their name.
+func parseStepConsts(pth string, content []byte) (stepConsts, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, pth, content, parser.ParseComments)
+	if err != nil {
+		return stepConsts{}, err
+	}
+
+	ids := map[string]stepConst{}
+	versions := map[string]stepConst{}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, name := range valueSpec.Names {
+				if i >= len(valueSpec.Values) {
+					continue
+				}
+				lit, ok := valueSpec.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				value, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return stepConsts{}, err
+				}
+
+				c := stepConst{
+					Name:  name.Name,
+					Value: value,
+					start: fset.Position(lit.Pos()).Offset,
+					end:   fset.Position(lit.End()).Offset,
+				}
+
+				if prefix, ok := stepConstPrefix(name.Name, stepIDConstSuffix); ok {
+					ids[prefix] = c
+				} else if prefix, ok := stepConstPrefix(name.Name, stepVersionConstSuffix); ok {
+					versions[prefix] = c
+				}
+			}
+		}
+	}
+
+	var consts stepConsts
+	for prefix, id := range ids {
+		version, ok := versions[prefix]
+		if !ok {
+			consts.OrphanIDs = append(consts.OrphanIDs, id)
+			continue
+		}
+		consts.Pairs = append(consts.Pairs, stepConstPair{ID: id, Version: version})
+	}
+	for prefix, version := range versions {
+		if _, ok := ids[prefix]; !ok {
+			consts.OrphanVersions = append(consts.OrphanVersions, version)
+		}
+	}
+
+	sort.Slice(consts.Pairs, func(i, j int) bool { return consts.Pairs[i].ID.start < consts.Pairs[j].ID.start })
+	sort.Slice(consts.OrphanIDs, func(i, j int) bool { return consts.OrphanIDs[i].start < consts.OrphanIDs[j].start })
+	sort.Slice(consts.OrphanVersions, func(i, j int) bool { return consts.OrphanVersions[i].start < consts.OrphanVersions[j].start })
+
+	return consts, nil
+}
+
+func stepConstPrefix(name, suffix string) (string, bool) {
+	prefix := strings.TrimSuffix(name, suffix)
+	return prefix, prefix != name && prefix != ""
+}
+
+// updateStepConsts replaces the version constants of the steps with the given versions
+// and formats the file, the comments and the other declarations are kept as they are.
+func updateStepConsts(content []byte, consts stepConsts, stepIDVersionMap map[string]string) ([]byte, []stepVersionUpdate, error) {
+	var updates []stepVersionUpdate
+	var replaced []stepConst
+	for _, pair := range consts.Pairs {
+		newVersion, ok := stepIDVersionMap[pair.ID.Value]
+		if !ok || newVersion == pair.Version.Value {
+			continue
+		}
+
+		updates = append(updates, stepVersionUpdate{StepID: pair.ID.Value, Version: pair.Version.Value, NewVersion: newVersion})
+		replaced = append(replaced, stepConst{Name: pair.Version.Name, Value: newVersion, start: pair.Version.start, end: pair.Version.end})
+	}
+
+	sort.Slice(replaced, func(i, j int) bool { return replaced[i].start < replaced[j].start })
+
+	var updated []byte
+	offset := 0
+	for _, c := range replaced {
+		updated = append(updated, content[offset:c.start]...)
+		updated = append(updated, strconv.Quote(c.Value)...)
+		offset = c.end
+	}
+	updated = append(updated, content[offset:]...)
+
+	formatted, err := format.Source(updated)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to format the updated file: %w", err)
+	}
+	return formatted, updates, nil
+}
+
+// stepIDFrom returns the step ID of a constant name prefix, like activate-ssh-key for ActivateSSHKey.
+func stepIDFrom(stepName string) string {
+	runes := []rune(stepName)
+
+	var words []string
+	wordStart := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		// A new word starts at an upper case letter following a lower case one,
+		// or at the last upper case letter of an acronym followed by a lower case one (SSHKey: SSH, Key).
+		if !unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, strings.ToLower(string(runes[wordStart:i])))
+			wordStart = i
+		}
+	}
+	if wordStart < len(runes) {
+		words = append(words, strings.ToLower(string(runes[wordStart:])))
+	}
+
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+const stepConstsContent = `package steps
+
+// GitClone ...
+const (
+	GitCloneID      = "git-clone"
+	GitCloneVersion = "8.0.0" // pinned by the e2e tests
+)
+
+const (
+	ActivateSSHKeyID = "activate-ssh-key"
+	// ActivateSSHKeyVersion ...
+	ActivateSSHKeyVersion = "4.1.0"
+	CacheID               = "cache"
+	ScriptVersion         = "1.1.5"
+	RetryCount            = 3
+	ID                    = "id"
+)
+
+var DefaultVersion = "1.0.0"
+`
+
+func TestParseStepConsts(t *testing.T) {
+	consts, err := parseStepConsts("const.go", []byte(stepConstsContent))
+	if err != nil {
+		t.Fatalf("parseStepConsts() error = %v", err)
+	}
+
+	var pairs [][]string
+	for _, pair := range consts.Pairs {
+		pairs = append(pairs, []string{pair.ID.Name, pair.ID.Value, pair.Version.Name, pair.Version.Value})
+	}
+	wantPairs := [][]string{
+		{"GitCloneID", "git-clone", "GitCloneVersion", "8.0.0"},
+		{"ActivateSSHKeyID", "activate-ssh-key", "ActivateSSHKeyVersion", "4.1.0"},
+	}
+	if !reflect.DeepEqual(pairs, wantPairs) {
+		t.Errorf("parseStepConsts() pairs = %v, want %v", pairs, wantPairs)
+	}
+
+	if names := stepConstNames(consts.OrphanIDs); !reflect.DeepEqual(names, []string{"CacheID"}) {
+		t.Errorf("parseStepConsts() orphan IDs = %v, want [CacheID]", names)
+	}
+	if names := stepConstNames(consts.OrphanVersions); !reflect.DeepEqual(names, []string{"ScriptVersion"}) {
+		t.Errorf("parseStepConsts() orphan versions = %v, want [ScriptVersion]", names)
+	}
+
+	if _, err := parseStepConsts("const.go", []byte("package steps\nconst (")); err == nil {
+		t.Errorf("parseStepConsts() expected an error for an invalid file")
+	}
+}
+
+func TestUpdateStepConsts(t *testing.T) {
+	tests := []struct {
+		name             string
+		stepIDVersionMap map[string]string
+		want             string
+		wantUpdates      []stepVersionUpdate
+	}{
+		{
+			name:             "no update",
+			stepIDVersionMap: map[string]string{"git-clone": "8.0.0", "unknown": "1.0.0"},
+			want:             stepConstsContent,
+		},
+		{
+			name:             "comments and alignment kept",
+			stepIDVersionMap: map[string]string{"git-clone": "8.10.1", "activate-ssh-key": "4.1.1", "cache": "2.0.0", "script": "1.2.0"},
+			want: `package steps
+
+// GitClone ...
+const (
+	GitCloneID      = "git-clone"
+	GitCloneVersion = "8.10.1" // pinned by the e2e tests
+)
+
+const (
+	ActivateSSHKeyID = "activate-ssh-key"
+	// ActivateSSHKeyVersion ...
+	ActivateSSHKeyVersion = "4.1.1"
+	CacheID               = "cache"
+	ScriptVersion         = "1.1.5"
+	RetryCount            = 3
+	ID                    = "id"
+)
+
+var DefaultVersion = "1.0.0"
+`,
+			wantUpdates: []stepVersionUpdate{
+				{StepID: "git-clone", Version: "8.0.0", NewVersion: "8.10.1"},
+				{StepID: "activate-ssh-key", Version: "4.1.0", NewVersion: "4.1.1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consts, err := parseStepConsts("const.go", []byte(stepConstsContent))
+			if err != nil {
+				t.Fatalf("parseStepConsts() error = %v", err)
+			}
+
+			got, updates, err := updateStepConsts([]byte(stepConstsContent), consts, tt.stepIDVersionMap)
+			if err != nil {
+				t.Fatalf("updateStepConsts() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("updateStepConsts() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !reflect.DeepEqual(updates, tt.wantUpdates) {
+				t.Errorf("updateStepConsts() updates = %+v, want %+v", updates, tt.wantUpdates)
+			}
+		})
+	}
+}
+
+func TestStepIDFrom(t *testing.T) {
+	tests := []struct {
+		stepName string
+		want     string
+	}{
+		{stepName: "GitClone", want: "git-clone"},
+		{stepName: "ActivateSSHKey", want: "activate-ssh-key"},
+		{stepName: "XcodeArchiveForIOS", want: "xcode-archive-for-ios"},
+		{stepName: "Script", want: "script"},
+		{stepName: "CocoapodsInstall", want: "cocoapods-install"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stepName, func(t *testing.T) {
+			if got := stepIDFrom(tt.stepName); got != tt.want {
+				t.Errorf("stepIDFrom() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func stepConstNames(consts []stepConst) []string {
+	var names []string
+	for _, c := range consts {
+		names = append(names, c.Name)
+	}
+	return names
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/godrei/stepper/tools"
+	"github.com/spf13/cobra"
+)
+
+var stepDiffCmd = &cobra.Command{
+	Use:   "stepDiff <step-id> <from-version> <to-version>",
+	Short: "Reports the interface changes of a step between two versions and classifies them as breaking or non-breaking.",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := log.NewLogger()
+		differ := StepDiffer{logger: logger}
+
+		if err := differ.Diff(args[0], args[1], args[2], stepDiffFormatFlag); err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var stepDiffFormatFlag string
+
+func init() {
+	RootCmd.AddCommand(stepDiffCmd)
+	stepDiffCmd.Flags().StringVarP(&stepDiffFormatFlag, "format", "", string(OutputFormatMarkdown), "Output format [markdown,json].")
+}
+
+// StepDiff ...
+type StepDiff struct {
+	StepID      string             `json:"step_id"`
+	FromVersion string             `json:"from_version"`
+	ToVersion   string             `json:"to_version"`
+	Breaking    bool               `json:"breaking"`
+	Changes     []tools.StepChange `json:"changes"`
+}
+
+// StepDiffer ...
+type StepDiffer struct {
+	logger log.Logger
+}
+
+// Diff ...
+func (d StepDiffer) Diff(stepID, fromVersion, toVersion, format string) error {
+	if format != string(OutputFormatMarkdown) && format != string(OutputFormatJSON) {
+		return fmt.Errorf("invalid format (%s), available: [markdown, json]", format)
+	}
+
+	source, err := steplibSource()
+	if err != nil {
+		return err
+	}
+
+	steplib, err := source.Spec(tools.ExportTypesFull)
+	if err != nil {
+		return err
+	}
+
+	stepGroup, ok := steplib.Steps[stepID]
+	if !ok {
+		return fmt.Errorf("step not found: %s", stepID)
+	}
+
+	from, ok := stepGroup.Versions[fromVersion]
+	if !ok {
+		return fmt.Errorf("step version not found: %s@%s", stepID, fromVersion)
+	}
+
+	to, ok := stepGroup.Versions[toVersion]
+	if !ok {
+		return fmt.Errorf("step version not found: %s@%s", stepID, toVersion)
+	}
+
+	changes, err := tools.DiffSteps(from, to)
+	if err != nil {
+		return err
+	}
+
+	diff := StepDiff{
+		StepID:      stepID,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Breaking:    tools.HasBreakingChange(changes),
+		Changes:     changes,
+	}
+
+	if format == string(OutputFormatJSON) {
+		out, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		d.logger.Printf("%s", out)
+		return nil
+	}
+
+	d.logger.Printf("%s", formatStepDiff(diff))
+	return nil
+}
+
+func formatStepDiff(diff StepDiff) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("## %s %s -> %s", diff.StepID, diff.FromVersion, diff.ToVersion))
+
+	if len(diff.Changes) == 0 {
+		lines = append(lines, "", "No interface changes.")
+		return strings.Join(lines, "\n")
+	}
+
+	var breaking, nonBreaking []string
+	for _, change := range diff.Changes {
+		if change.Breaking {
+			breaking = append(breaking, "- "+change.String())
+		} else {
+			nonBreaking = append(nonBreaking, "- "+change.String())
+		}
+	}
+
+	if len(breaking) > 0 {
+		lines = append(lines, "", "### Breaking changes", "")
+		lines = append(lines, breaking...)
+	}
+	if len(nonBreaking) > 0 {
+		lines = append(lines, "", "### Non-breaking changes", "")
+		lines = append(lines, nonBreaking...)
+	}
+
+	return strings.Join(lines, "\n")
+}
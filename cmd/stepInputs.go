@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/godrei/stepper/tools"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var stepInputsCmd = &cobra.Command{
+	Use:   "stepInputs",
+	Short: "Lists every input and output of the StepLib steps with their types, defaults and declaring steps.",
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := log.NewLogger()
+		inventory := StepInputInventory{logger: logger}
+
+		format, err := parseOutputFormat(stepInputsFormatFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+		if format == OutputFormatTemplate {
+			logger.Errorf("template format is not supported by the stepInputs command")
+			os.Exit(1)
+		}
+
+		versionsMode, err := parseVersionsMode(stepInputsVersionsFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
+		var stepIDs []string
+		if stepInputsStepIDsFlag != "" {
+			stepIDs = strings.Split(stepInputsStepIDsFlag, ",")
+		}
+
+		if err := inventory.Report(StepInputsOptions{
+			StepIDs:               stepIDs,
+			Versions:              versionsMode,
+			IgnoreDeprecatedSteps: stepInputsIgnoreDeprecatedFlag,
+			SkipOutputs:           stepInputsSkipOutputsFlag,
+			Format:                format,
+		}); err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	stepInputsStepIDsFlag          string
+	stepInputsVersionsFlag         string
+	stepInputsIgnoreDeprecatedFlag bool
+	stepInputsSkipOutputsFlag      bool
+	stepInputsFormatFlag           string
+)
+
+func init() {
+	RootCmd.AddCommand(stepInputsCmd)
+
+	stepInputsCmd.Flags().StringVarP(&stepInputsStepIDsFlag, "steps", "", "", "List of step IDs to report, separated by a comma character. Default: every step.")
+	stepInputsCmd.Flags().StringVarP(&stepInputsVersionsFlag, "versions", "", string(VersionsModeLatest), "Which versions of a step to report [latest,all,major-latest].")
+	stepInputsCmd.Flags().BoolVarP(&stepInputsIgnoreDeprecatedFlag, "ignore-deprecated", "", true, "Ignore deprecated steps.")
+	stepInputsCmd.Flags().BoolVarP(&stepInputsSkipOutputsFlag, "skip-outputs", "", false, "Report only the inputs.")
+	stepInputsCmd.Flags().StringVarP(&stepInputsFormatFlag, "format", "", string(OutputFormatMarkdown), "Output format [json,yaml,csv,markdown].")
+}
+
+// EnvKind ...
+type EnvKind string
+
+const (
+	// EnvKindInput ...
+	EnvKindInput EnvKind = "input"
+	// EnvKindOutput ...
+	EnvKindOutput EnvKind = "output"
+)
+
+// StepVersions lists the versions of a step declaring an input or output.
+type StepVersions struct {
+	StepID   string   `json:"step_id" yaml:"step_id"`
+	Versions []string `json:"versions" yaml:"versions"`
+}
+
+// EnvInventoryItem describes an input or output key across the steps.
+type EnvInventoryItem struct {
+	Kind EnvKind `json:"kind" yaml:"kind"`
+	Key  string  `json:"key" yaml:"key"`
+	// Types lists the distinct types the key is declared with, like 'string', 'select,required' or 'string,sensitive'.
+	Types []string `json:"types" yaml:"types"`
+	// Defaults lists the distinct default values of the key.
+	Defaults []string       `json:"defaults" yaml:"defaults"`
+	Steps    []StepVersions `json:"steps" yaml:"steps"`
+}
+
+// StepInputsOptions ...
+type StepInputsOptions struct {
+	StepIDs               []string
+	Versions              VersionsMode
+	IgnoreDeprecatedSteps bool
+	SkipOutputs           bool
+	Format                OutputFormat
+}
+
+// StepInputInventory ...
+type StepInputInventory struct {
+	logger log.Logger
+}
+
+// Report ...
+func (i StepInputInventory) Report(opts StepInputsOptions) error {
+	source, err := steplibSource()
+	if err != nil {
+		return err
+	}
+
+	steplib, err := source.Spec(tools.ExportTypesFull)
+	if err != nil {
+		return err
+	}
+
+	items, err := collectEnvInventory(steplib, opts)
+	if err != nil {
+		return err
+	}
+
+	out, err := formatEnvInventory(items, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	i.logger.Printf("%s", out)
+	return nil
+}
+
+func collectEnvInventory(steplib models.StepCollectionModel, opts StepInputsOptions) ([]EnvInventoryItem, error) {
+	type itemKey struct {
+		kind EnvKind
+		key  string
+	}
+
+	itemByKey := map[itemKey]*EnvInventoryItem{}
+	stepVersionsByKey := map[itemKey]map[string][]string{}
+
+	add := func(kind EnvKind, envs []envmanModels.EnvironmentItemModel, stepID, version string) error {
+		for _, env := range envs {
+			key, defaultValue, err := env.GetKeyValuePair()
+			if err != nil {
+				return err
+			}
+			options, err := env.GetOptions()
+			if err != nil {
+				return err
+			}
+
+			k := itemKey{kind: kind, key: key}
+			item, ok := itemByKey[k]
+			if !ok {
+				item = &EnvInventoryItem{Kind: kind, Key: key}
+				itemByKey[k] = item
+				stepVersionsByKey[k] = map[string][]string{}
+			}
+
+			item.Types = appendUnique(item.Types, envType(options))
+			item.Defaults = appendUnique(item.Defaults, defaultValue)
+			stepVersionsByKey[k][stepID] = append(stepVersionsByKey[k][stepID], version)
+		}
+		return nil
+	}
+
+	for stepID, stepGroup := range steplib.Steps {
+		if len(opts.StepIDs) > 0 && !slices.Contains(opts.StepIDs, stepID) {
+			continue
+		}
+
+		isDeprecated := stepGroup.Info.RemovalDate != "" || stepGroup.Info.DeprecateNotes != ""
+		if isDeprecated && opts.IgnoreDeprecatedSteps {
+			continue
+		}
+
+		latestVersion, err := tools.LatestVersionNumber(stepGroup)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", stepID, err)
+		}
+
+		var versions []string
+		for version := range stepGroup.Versions {
+			versions = append(versions, version)
+		}
+		allVersions, err := tools.SortVersionsDesc(versions)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", stepID, err)
+		}
+
+		reportedVersions, err := selectVersions(allVersions, latestVersion, opts.Versions)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", stepID, err)
+		}
+
+		for _, version := range reportedVersions {
+			step := stepGroup.Versions[version]
+
+			if err := add(EnvKindInput, step.Inputs, stepID, version); err != nil {
+				return nil, fmt.Errorf("step (%s@%s): %w", stepID, version, err)
+			}
+			if !opts.SkipOutputs {
+				if err := add(EnvKindOutput, step.Outputs, stepID, version); err != nil {
+					return nil, fmt.Errorf("step (%s@%s): %w", stepID, version, err)
+				}
+			}
+		}
+	}
+
+	var items []EnvInventoryItem
+	for k, item := range itemByKey {
+		var stepIDs []string
+		for stepID := range stepVersionsByKey[k] {
+			stepIDs = append(stepIDs, stepID)
+		}
+		sort.Strings(stepIDs)
+
+		for _, stepID := range stepIDs {
+			versions, err := tools.SortVersionsDesc(stepVersionsByKey[k][stepID])
+			if err != nil {
+				return nil, err
+			}
+			item.Steps = append(item.Steps, StepVersions{StepID: stepID, Versions: versions})
+		}
+
+		sort.Strings(item.Types)
+		sort.Strings(item.Defaults)
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind == EnvKindInput
+		}
+		return items[i].Key < items[j].Key
+	})
+
+	return items, nil
+}
+
+// envType derives the type of an input or output from its options.
+func envType(options envmanModels.EnvironmentItemOptionsModel) string {
+	components := []string{"string"}
+	if len(options.ValueOptions) > 0 {
+		components = []string{"select"}
+	}
+	if options.IsRequired != nil && *options.IsRequired {
+		components = append(components, "required")
+	}
+	if options.IsSensitive != nil && *options.IsSensitive {
+		components = append(components, "sensitive")
+	}
+	return strings.Join(components, ",")
+}
+
+func formatEnvInventory(items []EnvInventoryItem, format OutputFormat) (string, error) {
+	switch format {
+	case OutputFormatJSON:
+		out, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	case OutputFormatYAML:
+		out, err := yaml.Marshal(items)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	}
+
+	header := []string{"kind", "key", "types", "defaults", "steps"}
+	var rows [][]string
+	for _, item := range items {
+		var defaults []string
+		for _, defaultValue := range item.Defaults {
+			defaults = append(defaults, fmt.Sprintf("%q", defaultValue))
+		}
+
+		var steps []string
+		for _, step := range item.Steps {
+			steps = append(steps, fmt.Sprintf("%s@%s", step.StepID, strings.Join(step.Versions, ",")))
+		}
+
+		rows = append(rows, []string{string(item.Kind), item.Key, strings.Join(item.Types, " | "), strings.Join(defaults, " "), strings.Join(steps, " ")})
+	}
+
+	switch format {
+	case OutputFormatCSV:
+		return formatCSVRows(header, rows)
+	case OutputFormatMarkdown:
+		return formatMarkdownRows(header, rows), nil
+	default:
+		return "", fmt.Errorf("invalid format (%s), available: [json, yaml, csv, markdown]", format)
+	}
+}
+
+func appendUnique(items []string, item string) []string {
+	if slices.Contains(items, item) {
+		return items
+	}
+	return append(items, item)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-io/stepman/models"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCollectEnvInventory(t *testing.T) {
+	steplib := models.StepCollectionModel{
+		Steps: models.StepHash{
+			"git-clone": {
+				LatestVersionNumber: "8.1.0",
+				Versions: map[string]models.StepModel{
+					"8.0.0": stepModelFromYAML(t, `
+inputs:
+- clone_depth: ""
+- merge: "yes"
+  opts:
+    value_options: ["yes", "no"]
+outputs:
+- GIT_CLONE_COMMIT_HASH:
+`),
+					"8.1.0": stepModelFromYAML(t, `
+inputs:
+- clone_depth: "1"
+- merge: "no"
+  opts:
+    is_required: true
+    value_options: ["yes", "no"]
+- ssh_key: $SSH_KEY
+  opts:
+    is_sensitive: true
+outputs:
+- GIT_CLONE_COMMIT_HASH:
+`),
+				},
+			},
+			"script": {
+				LatestVersionNumber: "1.1.5",
+				Versions: map[string]models.StepModel{
+					"1.1.5": stepModelFromYAML(t, `
+inputs:
+- clone_depth: "1"
+  opts:
+    is_required: true
+`),
+				},
+			},
+			"old-step": {
+				LatestVersionNumber: "1.0.0",
+				Info:                models.StepGroupInfoModel{DeprecateNotes: "Use script instead."},
+				Versions: map[string]models.StepModel{
+					"1.0.0": stepModelFromYAML(t, `
+inputs:
+- legacy_input: ""
+`),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts StepInputsOptions
+		want []EnvInventoryItem
+	}{
+		{
+			name: "latest versions",
+			opts: StepInputsOptions{Versions: VersionsModeLatest, IgnoreDeprecatedSteps: true},
+			want: []EnvInventoryItem{
+				{Kind: EnvKindInput, Key: "clone_depth", Types: []string{"string", "string,required"}, Defaults: []string{"1"}, Steps: []StepVersions{{StepID: "git-clone", Versions: []string{"8.1.0"}}, {StepID: "script", Versions: []string{"1.1.5"}}}},
+				{Kind: EnvKindInput, Key: "merge", Types: []string{"select,required"}, Defaults: []string{"no"}, Steps: []StepVersions{{StepID: "git-clone", Versions: []string{"8.1.0"}}}},
+				{Kind: EnvKindInput, Key: "ssh_key", Types: []string{"string,sensitive"}, Defaults: []string{"$SSH_KEY"}, Steps: []StepVersions{{StepID: "git-clone", Versions: []string{"8.1.0"}}}},
+				{Kind: EnvKindOutput, Key: "GIT_CLONE_COMMIT_HASH", Types: []string{"string"}, Defaults: []string{""}, Steps: []StepVersions{{StepID: "git-clone", Versions: []string{"8.1.0"}}}},
+			},
+		},
+		{
+			name: "all versions of a step without outputs",
+			opts: StepInputsOptions{StepIDs: []string{"git-clone"}, Versions: VersionsModeAll, SkipOutputs: true},
+			want: []EnvInventoryItem{
+				{Kind: EnvKindInput, Key: "clone_depth", Types: []string{"string"}, Defaults: []string{"", "1"}, Steps: []StepVersions{{StepID: "git-clone", Versions: []string{"8.1.0", "8.0.0"}}}},
+				{Kind: EnvKindInput, Key: "merge", Types: []string{"select", "select,required"}, Defaults: []string{"no", "yes"}, Steps: []StepVersions{{StepID: "git-clone", Versions: []string{"8.1.0", "8.0.0"}}}},
+				{Kind: EnvKindInput, Key: "ssh_key", Types: []string{"string,sensitive"}, Defaults: []string{"$SSH_KEY"}, Steps: []StepVersions{{StepID: "git-clone", Versions: []string{"8.1.0"}}}},
+			},
+		},
+		{
+			name: "deprecated step",
+			opts: StepInputsOptions{StepIDs: []string{"old-step"}, Versions: VersionsModeLatest},
+			want: []EnvInventoryItem{
+				{Kind: EnvKindInput, Key: "legacy_input", Types: []string{"string"}, Defaults: []string{""}, Steps: []StepVersions{{StepID: "old-step", Versions: []string{"1.0.0"}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectEnvInventory(steplib, tt.opts)
+			if err != nil {
+				t.Fatalf("collectEnvInventory() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectEnvInventory() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func stepModelFromYAML(t *testing.T, content string) models.StepModel {
+	var step models.StepModel
+	if err := yaml.Unmarshal([]byte(content), &step); err != nil {
+		t.Fatalf("invalid step: %v", err)
+	}
+	return step
+}
@@ -1,14 +1,10 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
-	"unicode"
 
-	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/godrei/stepper/tools"
@@ -17,12 +13,21 @@ import (
 
 var (
 	flagStepsConstFilePath string
+	flagWriteStepLatests   bool
+	flagCheckStepLatests   bool
+	flagDiffStepLatests    bool
+	flagUpgradePolicy      string
+	flagMaxBump            string
+	flagHoldSteps          []string
 )
 
 var stepLatestsCmd = &cobra.Command{
 	Use:   "stepLatests",
 	Short: "Creates a steps/const.go file for bitrise-init tool with the current latest step versions.",
 	Run: func(cmd *cobra.Command, args []string) {
+		// Keep stdout for the generated file or the diff.
+		log.SetOutWriter(os.Stderr)
+
 		if err := stepLatests(); err != nil {
 			log.Errorf(err.Error())
 			os.Exit(1)
@@ -30,92 +35,11 @@ var stepLatestsCmd = &cobra.Command{
 	},
 }
 
-func collectStepIds(stepIDConstPth string) ([]string, error) {
-	content, err := fileutil.ReadStringFromFile(stepIDConstPth)
-	if err != nil {
-		return []string{}, err
-	}
-
-	// CertificateAndProfileInstallerID = "certificate-and-profile-installer"
-	pattern := `.*ID = "(?P<id>.*)"`
-	re := regexp.MustCompile(pattern)
-
-	reader := strings.NewReader(content)
-	scanner := bufio.NewScanner(reader)
-
-	stepIDs := []string{}
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if matches := re.FindStringSubmatch(line); len(matches) == 2 {
-			stepID := matches[1]
-			stepIDs = append(stepIDs, stepID)
-		}
-	}
-
-	return stepIDs, nil
-}
-
-func stepIDFrom(stepName string) string {
-	stepID := ""
-	l := 0
-	for s := stepName; s != ""; s = s[l:] {
-		l = strings.IndexFunc(s[1:], unicode.IsUpper) + 1
-		if l <= 0 {
-			l = len(s)
-		}
-		stepID += "-" + s[:l]
-	}
-	return stepID
-}
-
-func replaceStepVersions(stepIDConstPth string, stepIDVersionMap map[string]string) (string, error) {
-	content, err := fileutil.ReadStringFromFile(stepIDConstPth)
-	if err != nil {
-		return "", err
-	}
-
-	// CertificateAndProfileInstallerID = "certificate-and-profile-installer"
-	idPattern := `.*ID = "(?P<id>.*)"`
-	idRe := regexp.MustCompile(idPattern)
-
-	// CertificateAndProfileInstallerVersion = "1.8.4"
-	versionPattern := `.*Version = "(?P<version>.*)"`
-	versionRe := regexp.MustCompile(versionPattern)
-
-	reader := strings.NewReader(content)
-	scanner := bufio.NewScanner(reader)
-
-	currentStepID := ""
-
-	lines := []string{}
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if matches := idRe.FindStringSubmatch(line); len(matches) == 2 {
-			stepID := matches[1]
-			currentStepID = stepID
-
-			fmt.Printf("replacing step version: %s\n", currentStepID)
-		}
-
-		if matches := versionRe.FindStringSubmatch(line); len(matches) == 2 {
-			stepVersion := matches[1]
-
-			newVersion, ok := stepIDVersionMap[currentStepID]
-			if !ok {
-				return "", fmt.Errorf("no version found for: %s", currentStepID)
-			}
-
-			fmt.Printf("new version: %s\n", newVersion)
-
-			lines = append(lines, strings.Replace(line, stepVersion, newVersion, -1))
-		} else {
-			lines = append(lines, line)
-		}
-	}
-
-	return strings.Join(lines, "\n"), nil
+// stepVersionUpdate is an outdated step version of the const file.
+type stepVersionUpdate struct {
+	StepID     string
+	Version    string
+	NewVersion string
 }
 
 func stepLatests() error {
@@ -127,40 +51,116 @@ func stepLatests() error {
 	} else if !exist {
 		return fmt.Errorf("steps-const-file does not exist at: %s", flagStepsConstFilePath)
 	}
+	if flagCheckStepLatests && flagWriteStepLatests {
+		return fmt.Errorf("check and write flags can not be used together")
+	}
 
-	desiredStepIDs, err := collectStepIds(flagStepsConstFilePath)
+	policy, err := upgradePolicyFromFlags(flagUpgradePolicy, flagMaxBump, flagHoldSteps)
 	if err != nil {
 		return err
 	}
 
-	if err := tools.StepmanUpdate(defaultSteplibURI); err != nil {
+	content, err := os.ReadFile(flagStepsConstFilePath)
+	if err != nil {
 		return err
 	}
 
-	steplib, err := tools.StepmanExportSpec(defaultSteplibURI, tools.ExportTypesLatest)
+	consts, err := parseStepConsts(flagStepsConstFilePath, content)
+	if err != nil {
+		return err
+	}
+	for _, orphan := range consts.OrphanIDs {
+		log.Warnf("%s has no %s%s constant, the step is not updated", orphan.Name, strings.TrimSuffix(orphan.Name, stepIDConstSuffix), stepVersionConstSuffix)
+	}
+	for _, orphan := range consts.OrphanVersions {
+		prefix := strings.TrimSuffix(orphan.Name, stepVersionConstSuffix)
+		log.Warnf("%s has no %s%s constant (step: %s?), the version is not updated", orphan.Name, prefix, stepIDConstSuffix, stepIDFrom(prefix))
+	}
+
+	source, err := steplibSource()
+	if err != nil {
+		return err
+	}
+
+	steplib, err := source.Spec(tools.ExportTypesFull)
 	if err != nil {
 		return err
 	}
 
 	stepIDVersionMap := map[string]string{}
-	for stepID, stepGroup := range steplib.Steps {
-		for version := range stepGroup.Versions {
-			for _, desiredStepID := range desiredStepIDs {
-				if desiredStepID == stepID {
-					stepIDVersionMap[stepID] = version
-				}
-			}
+	var heldBackSteps []heldBackStep
+	for _, pair := range consts.Pairs {
+		stepID := pair.ID.Value
+		stepGroup, ok := steplib.Steps[stepID]
+		if !ok {
+			log.Warnf("step (%s) not found in the StepLib, the step is not updated", stepID)
 			continue
 		}
+
+		var versions []string
+		for version := range stepGroup.Versions {
+			versions = append(versions, version)
+		}
+
+		stepPolicy := policy.StepPolicy(stepID)
+		version, err := stepPolicy.allowedVersion(pair.Version.Value, versions)
+		if err != nil {
+			return fmt.Errorf("%s: %w", stepID, err)
+		}
+
+		stepIDVersionMap[stepID] = version
+		heldBack, ok, err := newHeldBackStep(stepID, stepPolicy, pair.Version.Value, version, versions)
+		if err != nil {
+			return fmt.Errorf("%s: %w", stepID, err)
+		}
+		if ok {
+			heldBackSteps = append(heldBackSteps, heldBack)
+		}
 	}
 
-	generatedContent, err := replaceStepVersions(flagStepsConstFilePath, stepIDVersionMap)
+	generated, updates, err := updateStepConsts(content, consts, stepIDVersionMap)
 	if err != nil {
 		return err
 	}
+	for _, update := range updates {
+		log.Printf("%s: %s -> %s", update.StepID, update.Version, update.NewVersion)
+	}
+	if len(heldBackSteps) > 0 {
+		log.Printf("")
+		log.Warnf("Held back step versions:")
+		for _, step := range heldBackSteps {
+			log.Printf("- %s: %s (latest: %s, %s)", step.StepID, step.Version, step.LatestVersion, step.Policy.Description())
+		}
+	}
+	generatedContent := string(generated)
+
+	if flagDiffStepLatests {
+		fmt.Print(tools.UnifiedDiff(flagStepsConstFilePath, flagStepsConstFilePath, string(content), generatedContent))
+	}
 
-	fmt.Println()
-	fmt.Printf("Generated:\n%s\n", generatedContent)
+	if flagWriteStepLatests {
+		if len(updates) == 0 {
+			log.Donef("%s is up to date", flagStepsConstFilePath)
+			return nil
+		}
+		if err := tools.WriteFileAtomically(flagStepsConstFilePath, generated); err != nil {
+			return err
+		}
+		log.Donef("Updated %d step version(s) in %s", len(updates), flagStepsConstFilePath)
+		return nil
+	}
+
+	if flagCheckStepLatests {
+		if len(updates) > 0 {
+			return fmt.Errorf("%d step version(s) are outdated in %s", len(updates), flagStepsConstFilePath)
+		}
+		log.Donef("%s is up to date", flagStepsConstFilePath)
+		return nil
+	}
+
+	if !flagDiffStepLatests {
+		fmt.Print(generatedContent)
+	}
 
 	return nil
 }
@@ -168,4 +168,10 @@ func stepLatests() error {
 func init() {
 	RootCmd.AddCommand(stepLatestsCmd)
 	stepLatestsCmd.Flags().StringVarP(&flagStepsConstFilePath, "steps-const-file", "", "", "Path to the local steps/const.go file in the bitrise-init project.")
+	stepLatestsCmd.Flags().BoolVarP(&flagWriteStepLatests, "write", "", false, "Update the steps-const-file in place.")
+	stepLatestsCmd.Flags().BoolVarP(&flagCheckStepLatests, "check", "", false, "Exit with an error if any step version of the steps-const-file is outdated.")
+	stepLatestsCmd.Flags().BoolVarP(&flagDiffStepLatests, "diff", "", false, "Print the unified diff of the steps-const-file update.")
+	stepLatestsCmd.Flags().StringVarP(&flagUpgradePolicy, "policy", "", "", "Path to the upgrade policy yaml file (max_bump and per step max_bump, hold and reason).")
+	stepLatestsCmd.Flags().StringVarP(&flagMaxBump, "max-bump", "", "", "Largest allowed version bump of the steps: major (latest version), minor (stay on the current major) or patch (stay on the current minor). Overrides the policy file's max_bump.")
+	stepLatestsCmd.Flags().StringArrayVarP(&flagHoldSteps, "hold", "", nil, "ID of a step to keep on its current version. Can be specified multiple times.")
 }
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/godrei/stepper/tools"
+)
+
+// StepLifecycleEntry is a deprecated or removed step of the report.
+type StepLifecycleEntry struct {
+	StepID         string `json:"step_id"`
+	LatestVersion  string `json:"latest_version"`
+	SourceURL      string `json:"source_url,omitempty"`
+	DeprecateNotes string `json:"deprecate_notes,omitempty"`
+	RemovalDate    string `json:"removal_date,omitempty"`
+	// Replacements lists the steps suggested by the deprecation notes.
+	Replacements []string `json:"replacements,omitempty"`
+}
+
+// Details returns the deprecation notes and the suggested replacements, like ': Use script instead (suggested replacement: script)'.
+func (e StepLifecycleEntry) Details() string {
+	var details string
+	if e.DeprecateNotes != "" {
+		details += ": " + strings.Join(strings.Fields(e.DeprecateNotes), " ")
+	}
+	if len(e.Replacements) > 0 {
+		details += fmt.Sprintf(" (suggested replacement: %s)", strings.Join(e.Replacements, ", "))
+	}
+	return details
+}
+
+func isStepDeprecated(stepGroup models.StepGroupModel) bool {
+	return stepGroup.Info.RemovalDate != "" || stepGroup.Info.DeprecateNotes != ""
+}
+
+// collectStepLifecycleChanges compares the StepLib spec at the start of the window (previous) to the current spec.
+//
+// A step is removed in the window if it is missing from the current spec or its removal date is in the window.
+// A step is deprecated in the window if it was not deprecated in the previous spec, but it is in the current one,
+// without a previous spec the deprecated steps can not be told.
+func collectStepLifecycleChanges(previous *models.StepCollectionModel, current models.StepCollectionModel, start, end time.Time) ([]StepLifecycleEntry, []StepLifecycleEntry, error) {
+	deprecated := []StepLifecycleEntry{}
+	removed := []StepLifecycleEntry{}
+
+	for stepID, stepGroup := range current.Steps {
+		if isRemovedInWindow(stepGroup, start, end) {
+			entry, ok, err := newStepLifecycleEntry(current, stepID, stepGroup)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				removed = append(removed, entry)
+			}
+			continue
+		}
+
+		if previous == nil || !isStepDeprecated(stepGroup) {
+			continue
+		}
+		if previousStepGroup, ok := previous.Steps[stepID]; ok && isStepDeprecated(previousStepGroup) {
+			continue
+		}
+
+		entry, ok, err := newStepLifecycleEntry(current, stepID, stepGroup)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			deprecated = append(deprecated, entry)
+		}
+	}
+
+	if previous != nil {
+		for stepID, stepGroup := range previous.Steps {
+			if _, ok := current.Steps[stepID]; ok {
+				continue
+			}
+
+			entry, ok, err := newStepLifecycleEntry(current, stepID, stepGroup)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				removed = append(removed, entry)
+			}
+		}
+	}
+
+	sort.Slice(deprecated, func(i, j int) bool { return deprecated[i].StepID < deprecated[j].StepID })
+	sort.Slice(removed, func(i, j int) bool { return removed[i].StepID < removed[j].StepID })
+
+	return deprecated, removed, nil
+}
+
+func isRemovedInWindow(stepGroup models.StepGroupModel, start, end time.Time) bool {
+	if stepGroup.Info.RemovalDate == "" {
+		return false
+	}
+
+	removalDate, err := parseTimeFlag(stepGroup.Info.RemovalDate)
+	if err != nil {
+		return false
+	}
+	return removalDate.After(start) && !removalDate.After(end)
+}
+
+// newStepLifecycleEntry creates the entry of the step group, the replacements are looked up in the current spec.
+// A step group without versions is skipped with a warning.
+func newStepLifecycleEntry(current models.StepCollectionModel, stepID string, stepGroup models.StepGroupModel) (StepLifecycleEntry, bool, error) {
+	if len(stepGroup.Versions) == 0 {
+		log.Warnf("Step (%s) has no versions, skipping it", stepID)
+		return StepLifecycleEntry{}, false, nil
+	}
+
+	latestVersion, err := tools.LatestVersionNumber(stepGroup)
+	if err != nil {
+		return StepLifecycleEntry{}, false, err
+	}
+
+	return StepLifecycleEntry{
+		StepID:         stepID,
+		LatestVersion:  latestVersion,
+		SourceURL:      stepSourceURL(stepGroup.Versions[latestVersion]),
+		DeprecateNotes: strings.TrimSpace(stepGroup.Info.DeprecateNotes),
+		RemovalDate:    stepGroup.Info.RemovalDate,
+		Replacements:   suggestedReplacements(current, stepID, stepGroup.Info.DeprecateNotes),
+	}, true, nil
+}
+
+// stepSourceURL returns the source repository URL of the step version without the .git suffix,
+// empty if the step version has no source in the spec.
+func stepSourceURL(step models.StepModel) string {
+	if step.Source == nil {
+		return ""
+	}
+	return strings.TrimSuffix(step.Source.Git, ".git")
+}
+
+var replacementCandidatePatterns = []*regexp.Regexp{
+	// `step-id`, 'step-id' or "step-id"
+	regexp.MustCompile("[`'\"]([a-zA-Z0-9_-]+)[`'\"]"),
+	// use (the) step-id (step)
+	regexp.MustCompile(`(?i)\buse (?:the )?([a-zA-Z0-9_-]+)`),
+	// step-id step
+	regexp.MustCompile(`(?i)\b([a-zA-Z0-9_-]+) step\b`),
+	// https://bitrise.io/integrations/steps/step-id
+	regexp.MustCompile(`/steps/([a-zA-Z0-9_-]+)`),
+}
+
+// suggestedReplacements returns the not deprecated steps mentioned in the deprecation notes.
+func suggestedReplacements(steplib models.StepCollectionModel, stepID, deprecateNotes string) []string {
+	var replacements []string
+	for _, pattern := range replacementCandidatePatterns {
+		for _, match := range pattern.FindAllStringSubmatch(deprecateNotes, -1) {
+			candidate := match[1]
+			if candidate == stepID {
+				continue
+			}
+
+			stepGroup, ok := steplib.Steps[candidate]
+			if !ok || isStepDeprecated(stepGroup) {
+				continue
+			}
+
+			replacements = appendUnique(replacements, candidate)
+		}
+	}
+	return replacements
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/stepman/models"
+)
+
+func TestCollectStepLifecycleChanges(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	stepGroup := func(info models.StepGroupInfoModel, source string) models.StepGroupModel {
+		step := models.StepModel{}
+		if source != "" {
+			step.Source = &models.StepSourceModel{Git: source}
+		}
+		return models.StepGroupModel{
+			Info:                info,
+			LatestVersionNumber: "1.0.0",
+			Versions:            map[string]models.StepModel{"1.0.0": step},
+		}
+	}
+
+	previous := models.StepCollectionModel{
+		Steps: models.StepHash{
+			"script":           stepGroup(models.StepGroupInfoModel{}, ""),
+			"old-script":       stepGroup(models.StepGroupInfoModel{}, "https://github.com/bitrise-steplib/steps-old-script.git"),
+			"legacy-step":      stepGroup(models.StepGroupInfoModel{DeprecateNotes: "Use script."}, ""),
+			"retired-step":     stepGroup(models.StepGroupInfoModel{}, ""),
+			"deleted-step":     stepGroup(models.StepGroupInfoModel{}, "https://github.com/bitrise-steplib/steps-deleted-step.git"),
+			"later-removed":    stepGroup(models.StepGroupInfoModel{}, ""),
+			"past-removed":     stepGroup(models.StepGroupInfoModel{RemovalDate: "2024-02-01"}, ""),
+			"deleted-no-steps": {},
+		},
+	}
+	current := models.StepCollectionModel{
+		Steps: models.StepHash{
+			"script":        stepGroup(models.StepGroupInfoModel{}, ""),
+			"old-script":    stepGroup(models.StepGroupInfoModel{DeprecateNotes: "Use the `script` step instead."}, "https://github.com/bitrise-steplib/steps-old-script.git"),
+			"legacy-step":   stepGroup(models.StepGroupInfoModel{DeprecateNotes: "Use script."}, ""),
+			"retired-step":  stepGroup(models.StepGroupInfoModel{DeprecateNotes: "Use old-script.", RemovalDate: "2024-03-10"}, ""),
+			"later-removed": stepGroup(models.StepGroupInfoModel{RemovalDate: "2024-04-01"}, ""),
+			"past-removed":  stepGroup(models.StepGroupInfoModel{RemovalDate: "2024-02-01"}, ""),
+		},
+	}
+
+	tests := []struct {
+		name           string
+		previous       *models.StepCollectionModel
+		wantDeprecated []StepLifecycleEntry
+		wantRemoved    []StepLifecycleEntry
+	}{
+		{
+			name:     "with previous spec",
+			previous: &previous,
+			wantDeprecated: []StepLifecycleEntry{
+				{StepID: "later-removed", LatestVersion: "1.0.0", RemovalDate: "2024-04-01"},
+				{StepID: "old-script", LatestVersion: "1.0.0", SourceURL: "https://github.com/bitrise-steplib/steps-old-script", DeprecateNotes: "Use the `script` step instead.", Replacements: []string{"script"}},
+			},
+			wantRemoved: []StepLifecycleEntry{
+				{StepID: "deleted-step", LatestVersion: "1.0.0", SourceURL: "https://github.com/bitrise-steplib/steps-deleted-step"},
+				{StepID: "retired-step", LatestVersion: "1.0.0", DeprecateNotes: "Use old-script.", RemovalDate: "2024-03-10"},
+			},
+		},
+		{
+			name:           "without previous spec",
+			wantDeprecated: []StepLifecycleEntry{},
+			wantRemoved: []StepLifecycleEntry{
+				{StepID: "retired-step", LatestVersion: "1.0.0", DeprecateNotes: "Use old-script.", RemovalDate: "2024-03-10"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deprecated, removed, err := collectStepLifecycleChanges(tt.previous, current, start, end)
+			if err != nil {
+				t.Fatalf("collectStepLifecycleChanges() error = %v", err)
+			}
+			if !reflect.DeepEqual(deprecated, tt.wantDeprecated) {
+				t.Errorf("collectStepLifecycleChanges() deprecated =\n%+v\nwant\n%+v", deprecated, tt.wantDeprecated)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("collectStepLifecycleChanges() removed =\n%+v\nwant\n%+v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestSuggestedReplacements(t *testing.T) {
+	steplib := models.StepCollectionModel{
+		Steps: models.StepHash{
+			"script":      {},
+			"git-clone":   {},
+			"old-script":  {Info: models.StepGroupInfoModel{DeprecateNotes: "Use script."}},
+			"cache-pull":  {},
+			"restore-npm": {},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		notes string
+		want  []string
+	}{
+		{name: "quoted step id", notes: "Use `script` instead.", want: []string{"script"}},
+		{name: "use the step", notes: "Please use the git-clone step.", want: []string{"git-clone"}},
+		{name: "integrations link", notes: "Moved to https://bitrise.io/integrations/steps/restore-npm", want: []string{"restore-npm"}},
+		{name: "multiple steps once each", notes: "Use 'cache-pull' or the restore-npm step, cache-pull step is faster.", want: []string{"cache-pull", "restore-npm"}},
+		{name: "deprecated and unknown steps skipped", notes: "Use `old-script` or `unknown-step`.", want: nil},
+		{name: "the deprecated step itself skipped", notes: "The `deprecated-step` is not maintained.", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestedReplacements(steplib, "deprecated-step", tt.notes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suggestedReplacements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/godrei/stepper/tools"
+	ver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+)
+
+var stepSemverCheckCmd = &cobra.Command{
+	Use:   "stepSemverCheck",
+	Short: "Validates that the version bump of every step release since the given time matches the step's interface changes.",
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := log.NewLogger()
+		checker := StepSemverChecker{logger: logger}
+
+		if semverCheckStartFlag == "" {
+			logger.Errorf("start not defined")
+			os.Exit(1)
+		}
+		start, err := parseTimeFlag(semverCheckStartFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
+		var stepIDs []string
+		if semverCheckStepIDsFlag != "" {
+			stepIDs = strings.Split(semverCheckStepIDsFlag, ",")
+		}
+
+		if err := checker.Check(SemverCheckOptions{
+			Start:   start,
+			StepIDs: stepIDs,
+			Strict:  semverCheckStrictFlag,
+			Format:  semverCheckFormatFlag,
+		}); err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	semverCheckStartFlag   string
+	semverCheckStepIDsFlag string
+	semverCheckStrictFlag  bool
+	semverCheckFormatFlag  string
+)
+
+func init() {
+	RootCmd.AddCommand(stepSemverCheckCmd)
+	stepSemverCheckCmd.Flags().StringVarP(&semverCheckStartFlag, "start", "", "", "Check the releases published after this time. Format: 2006-01-02 or RFC3339.")
+	stepSemverCheckCmd.Flags().StringVarP(&semverCheckStepIDsFlag, "steps", "", "", "List of step IDs to check, separated by a comma character. Default: every step.")
+	stepSemverCheckCmd.Flags().BoolVarP(&semverCheckStrictFlag, "strict", "", false, "Fail on warnings too (new inputs or outputs released as a patch).")
+	stepSemverCheckCmd.Flags().StringVarP(&semverCheckFormatFlag, "format", "", string(OutputFormatMarkdown), "Output format [markdown,json].")
+}
+
+// SemverIssueSeverity ...
+type SemverIssueSeverity string
+
+const (
+	// SemverIssueError means a breaking change was released without a major version bump.
+	SemverIssueError SemverIssueSeverity = "error"
+	// SemverIssueWarning means a new feature was released as a patch.
+	SemverIssueWarning SemverIssueSeverity = "warning"
+)
+
+// SemverIssue is a suspicious step release.
+type SemverIssue struct {
+	StepID          string              `json:"step_id"`
+	PreviousVersion string              `json:"previous_version"`
+	Version         string              `json:"version"`
+	Bump            tools.BumpType      `json:"bump"`
+	Severity        SemverIssueSeverity `json:"severity"`
+	Changes         []tools.StepChange  `json:"changes"`
+}
+
+// SemverCheckOptions ...
+type SemverCheckOptions struct {
+	Start   time.Time
+	StepIDs []string
+	Strict  bool
+	Format  string
+}
+
+// StepSemverChecker ...
+type StepSemverChecker struct {
+	logger log.Logger
+}
+
+// Check ...
+func (c StepSemverChecker) Check(opts SemverCheckOptions) error {
+	if opts.Format != string(OutputFormatMarkdown) && opts.Format != string(OutputFormatJSON) {
+		return fmt.Errorf("invalid format (%s), available: [markdown, json]", opts.Format)
+	}
+
+	source, err := steplibSource()
+	if err != nil {
+		return err
+	}
+
+	steplib, err := source.Spec(tools.ExportTypesFull)
+	if err != nil {
+		return err
+	}
+
+	issues, err := checkReleaseBumps(steplib, opts.Start, opts.StepIDs)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == string(OutputFormatJSON) {
+		out, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return err
+		}
+		c.logger.Printf("%s", out)
+	} else {
+		c.logger.Printf("%s", formatSemverIssues(issues))
+	}
+
+	failing := 0
+	for _, issue := range issues {
+		if issue.Severity == SemverIssueError || opts.Strict {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d suspicious release(s) found", failing)
+	}
+
+	return nil
+}
+
+// checkReleaseBumps compares every version published after the start time to the previous version of the step.
+func checkReleaseBumps(steplib models.StepCollectionModel, start time.Time, stepIDs []string) ([]SemverIssue, error) {
+	var issues []SemverIssue
+
+	for stepID, stepGroup := range steplib.Steps {
+		if len(stepIDs) > 0 && !slices.Contains(stepIDs, stepID) {
+			continue
+		}
+
+		var versions []string
+		for version := range stepGroup.Versions {
+			versions = append(versions, version)
+		}
+		versions, err := tools.SortVersionsDesc(versions)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", stepID, err)
+		}
+
+		for i := 0; i < len(versions)-1; i++ {
+			version, previousVersion := versions[i], versions[i+1]
+
+			step := stepGroup.Versions[version]
+			if step.PublishedAt == nil || !step.PublishedAt.After(start) {
+				continue
+			}
+
+			issue, found, err := checkReleaseBump(stepID, previousVersion, stepGroup.Versions[previousVersion], version, step)
+			if err != nil {
+				return nil, fmt.Errorf("step (%s@%s): %w", stepID, version, err)
+			}
+			if found {
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].StepID != issues[j].StepID {
+			return issues[i].StepID < issues[j].StepID
+		}
+		return ver.Must(ver.NewVersion(issues[i].Version)).LessThan(ver.Must(ver.NewVersion(issues[j].Version)))
+	})
+
+	return issues, nil
+}
+
+func checkReleaseBump(stepID, previousVersion string, previous models.StepModel, version string, step models.StepModel) (SemverIssue, bool, error) {
+	bump, err := tools.VersionBump(previousVersion, version)
+	if err != nil {
+		return SemverIssue{}, false, err
+	}
+
+	changes, err := tools.DiffSteps(previous, step)
+	if err != nil {
+		return SemverIssue{}, false, err
+	}
+
+	issue := SemverIssue{
+		StepID:          stepID,
+		PreviousVersion: previousVersion,
+		Version:         version,
+		Bump:            bump,
+		Changes:         changes,
+	}
+
+	if bump != tools.BumpTypeMajor && tools.HasBreakingChange(changes) {
+		issue.Severity = SemverIssueError
+		return issue, true, nil
+	}
+
+	if bump == tools.BumpTypePatch && hasFeatureChange(changes) {
+		issue.Severity = SemverIssueWarning
+		return issue, true, nil
+	}
+
+	return SemverIssue{}, false, nil
+}
+
+func hasFeatureChange(changes []tools.StepChange) bool {
+	for _, change := range changes {
+		switch change.Kind {
+		case tools.StepChangeInputAdded, tools.StepChangeOutputAdded:
+			return true
+		}
+	}
+	return false
+}
+
+func formatSemverIssues(issues []SemverIssue) string {
+	if len(issues) == 0 {
+		return "No suspicious releases found."
+	}
+
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, fmt.Sprintf("- [%s] __%s %s__ released as a %s bump of %s:", issue.Severity, issue.StepID, issue.Version, issue.Bump, issue.PreviousVersion))
+		for _, change := range issue.Changes {
+			if issue.Severity == SemverIssueError && !change.Breaking {
+				continue
+			}
+			lines = append(lines, "  - "+change.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
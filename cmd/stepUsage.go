@@ -0,0 +1,484 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/godrei/stepper/tools"
+	ver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var stepUsageCmd = &cobra.Command{
+	Use:   "stepUsage [path...]",
+	Short: "Reports the StepLib steps referenced by the bitrise.yml files of the given dirs with their versions, workflow counts and deprecated or outdated status.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := log.NewLogger()
+		usage := StepUsage{logger: logger}
+
+		format, err := parseOutputFormat(stepUsageFormatFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+		if format == OutputFormatTemplate {
+			logger.Errorf("template format is not supported by the stepUsage command")
+			os.Exit(1)
+		}
+
+		if err := usage.Report(StepUsageOptions{
+			Paths:  args,
+			Format: format,
+		}); err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	stepUsageFormatFlag string
+)
+
+func init() {
+	RootCmd.AddCommand(stepUsageCmd)
+
+	stepUsageCmd.Flags().StringVarP(&stepUsageFormatFlag, "format", "", string(OutputFormatMarkdown), "Output format [json,yaml,csv,markdown].")
+}
+
+// StepUsageStatus tells how the referenced version relates to the latest version of the step.
+type StepUsageStatus string
+
+const (
+	// StepUsageStatusUpToDate means the reference resolves to the latest version.
+	StepUsageStatusUpToDate StepUsageStatus = "up_to_date"
+	// StepUsageStatusOutdated means the reference resolves to an older version of the latest major.
+	StepUsageStatusOutdated StepUsageStatus = "outdated"
+	// StepUsageStatusOutdatedMajor means the reference resolves to an older major version.
+	StepUsageStatusOutdatedMajor StepUsageStatus = "outdated_major"
+	// StepUsageStatusUnknownVersion means no StepLib version matches the reference or the reference is not a version, like script@1.x.
+	StepUsageStatusUnknownVersion StepUsageStatus = "unknown_version"
+	// StepUsageStatusUnknownStep means the step is not in the StepLib.
+	StepUsageStatusUnknownStep StepUsageStatus = "unknown_step"
+)
+
+// StepVersionUsage is a referenced version of a step, like 8 for git-clone@8.
+type StepVersionUsage struct {
+	// Version is the referenced version, empty if the reference is not pinned.
+	Version string `json:"version" yaml:"version"`
+	// ResolvedVersion is the StepLib version the reference runs.
+	ResolvedVersion string          `json:"resolved_version,omitempty" yaml:"resolved_version,omitempty"`
+	Status          StepUsageStatus `json:"status" yaml:"status"`
+	// Deprecated tells if the resolved version is deprecated.
+	// The StepLib keeps the deprecation per step (step-info.yml), so every resolved version of a deprecated step is deprecated.
+	Deprecated bool `json:"deprecated" yaml:"deprecated"`
+	Workflows  int  `json:"workflows" yaml:"workflows"`
+	Files      int  `json:"files" yaml:"files"`
+}
+
+// StepUsageItem is the usage of a StepLib step across the bitrise.yml files.
+type StepUsageItem struct {
+	StepID        string `json:"step_id" yaml:"step_id"`
+	LatestVersion string `json:"latest_version,omitempty" yaml:"latest_version,omitempty"`
+	Deprecated    bool   `json:"deprecated" yaml:"deprecated"`
+	// Workflows is the number of workflows running the step, directly or through their before_run and after_run workflows and step bundles.
+	Workflows int                `json:"workflows" yaml:"workflows"`
+	Files     int                `json:"files" yaml:"files"`
+	Versions  []StepVersionUsage `json:"versions" yaml:"versions"`
+}
+
+// StepUsageOptions ...
+type StepUsageOptions struct {
+	// Paths are bitrise.yml files or dirs, the dirs are walked for bitrise.yml and bitrise.yaml files.
+	Paths  []string
+	Format OutputFormat
+}
+
+// StepUsage ...
+type StepUsage struct {
+	logger log.Logger
+}
+
+// Report ...
+func (u StepUsage) Report(opts StepUsageOptions) error {
+	files, err := collectMatchingFiles(opts.Paths, []string{"bitrise.yml", "bitrise.yaml"})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no bitrise.yml files found")
+	}
+
+	source, err := steplibSource()
+	if err != nil {
+		return err
+	}
+
+	steplib, err := source.Spec(tools.ExportTypesFull)
+	if err != nil {
+		return err
+	}
+
+	var references []workflowStepReference
+	for _, pth := range files {
+		content, err := os.ReadFile(pth)
+		if err != nil {
+			return err
+		}
+
+		var config bitriseConfig
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			u.logger.Warnf("Skipping %s: invalid bitrise.yml: %s", pth, err)
+			continue
+		}
+
+		for _, workflow := range sortedWorkflowNames(config) {
+			for _, reference := range config.workflowStepReferences(workflow) {
+				reference.File = pth
+				reference.Workflow = workflow
+				references = append(references, reference)
+			}
+		}
+	}
+
+	items, err := collectStepUsage(steplib, references)
+	if err != nil {
+		return err
+	}
+
+	out, err := formatStepUsage(items, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Printf("%s", out)
+	return nil
+}
+
+// bitriseConfig is the part of the bitrise.yml, which tells the steps of the workflows.
+type bitriseConfig struct {
+	Workflows   map[string]bitriseWorkflow   `yaml:"workflows"`
+	StepBundles map[string]bitriseStepBundle `yaml:"step_bundles"`
+}
+
+type bitriseWorkflow struct {
+	BeforeRun []string              `yaml:"before_run"`
+	AfterRun  []string              `yaml:"after_run"`
+	Steps     []bitriseStepListItem `yaml:"steps"`
+}
+
+type bitriseStepBundle struct {
+	Steps []bitriseStepListItem `yaml:"steps"`
+}
+
+// bitriseStepListItem is an item of a steps list: a step (git-clone@8), a step bundle (bundle::setup) or a with group (with).
+type bitriseStepListItem map[string]bitriseStepListItemValue
+
+type bitriseStepListItemValue struct {
+	// Steps are the steps of a with group.
+	Steps []bitriseStepListItem `yaml:"steps"`
+}
+
+// workflowStepReference is a StepLib step run by a workflow.
+type workflowStepReference struct {
+	File     string
+	Workflow string
+	StepID   string
+	Version  string
+}
+
+func sortedWorkflowNames(config bitriseConfig) []string {
+	var names []string
+	for name := range config.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// workflowStepReferences returns the StepLib steps run by the workflow,
+// including the steps of its before_run and after_run workflows and of the step bundles.
+func (c bitriseConfig) workflowStepReferences(workflow string) []workflowStepReference {
+	var references []workflowStepReference
+	visitedWorkflows := map[string]bool{}
+
+	var addSteps func(items []bitriseStepListItem, visitedBundles map[string]bool)
+	addSteps = func(items []bitriseStepListItem, visitedBundles map[string]bool) {
+		for _, item := range items {
+			for key, value := range item {
+				if key == "with" {
+					addSteps(value.Steps, visitedBundles)
+					continue
+				}
+
+				if bundle, ok := strings.CutPrefix(key, "bundle::"); ok {
+					if visitedBundles[bundle] {
+						continue
+					}
+					visitedBundles[bundle] = true
+					addSteps(c.StepBundles[bundle].Steps, visitedBundles)
+					delete(visitedBundles, bundle)
+					continue
+				}
+
+				if stepID, version, ok := parseSteplibStepReference(key); ok {
+					references = append(references, workflowStepReference{StepID: stepID, Version: version})
+				}
+			}
+		}
+	}
+
+	var addWorkflow func(name string)
+	addWorkflow = func(name string) {
+		if visitedWorkflows[name] {
+			return
+		}
+		visitedWorkflows[name] = true
+
+		w := c.Workflows[name]
+		for _, beforeRun := range w.BeforeRun {
+			addWorkflow(beforeRun)
+		}
+		addSteps(w.Steps, map[string]bool{})
+		for _, afterRun := range w.AfterRun {
+			addWorkflow(afterRun)
+		}
+	}
+
+	addWorkflow(workflow)
+	return references
+}
+
+// parseSteplibStepReference returns the ID and the version of a StepLib step reference, like git-clone@8
+// or https://github.com/bitrise-io/bitrise-steplib.git::git-clone@8. The git::, path:: and other StepLib steps are not StepLib step references.
+func parseSteplibStepReference(reference string) (string, string, bool) {
+	if steplibURI, stepReference, ok := strings.Cut(reference, "::"); ok {
+		if steplibURI != defaultSteplibURI && steplibURI != flagSteplib {
+			return "", "", false
+		}
+		reference = stepReference
+	}
+
+	stepID, version, _ := strings.Cut(reference, "@")
+	if stepID == "" {
+		return "", "", false
+	}
+	return stepID, version, true
+}
+
+func collectStepUsage(steplib models.StepCollectionModel, references []workflowStepReference) ([]StepUsageItem, error) {
+	type usageKey struct {
+		stepID  string
+		version string
+	}
+
+	workflowsByStep := map[string]map[string]bool{}
+	filesByStep := map[string]map[string]bool{}
+	workflowsByVersion := map[usageKey]map[string]bool{}
+	filesByVersion := map[usageKey]map[string]bool{}
+
+	add := func(m map[string]map[string]bool, key, value string) {
+		if m[key] == nil {
+			m[key] = map[string]bool{}
+		}
+		m[key][value] = true
+	}
+
+	for _, reference := range references {
+		workflow := reference.File + "#" + reference.Workflow
+		add(workflowsByStep, reference.StepID, workflow)
+		add(filesByStep, reference.StepID, reference.File)
+
+		k := usageKey{stepID: reference.StepID, version: reference.Version}
+		if workflowsByVersion[k] == nil {
+			workflowsByVersion[k] = map[string]bool{}
+			filesByVersion[k] = map[string]bool{}
+		}
+		workflowsByVersion[k][workflow] = true
+		filesByVersion[k][reference.File] = true
+	}
+
+	versionsByStep := map[string][]string{}
+	for k := range workflowsByVersion {
+		versionsByStep[k.stepID] = append(versionsByStep[k.stepID], k.version)
+	}
+
+	var items []StepUsageItem
+	for stepID, referencedVersions := range versionsByStep {
+		item := StepUsageItem{
+			StepID:    stepID,
+			Workflows: len(workflowsByStep[stepID]),
+			Files:     len(filesByStep[stepID]),
+		}
+
+		stepGroup, inSteplib := steplib.Steps[stepID]
+		var versions []string
+		if inSteplib {
+			latestVersion, err := tools.LatestVersionNumber(stepGroup)
+			if err != nil {
+				return nil, fmt.Errorf("step (%s): %w", stepID, err)
+			}
+			item.LatestVersion = latestVersion
+			item.Deprecated = isStepDeprecated(stepGroup)
+
+			for version := range stepGroup.Versions {
+				versions = append(versions, version)
+			}
+		}
+
+		for _, version := range referencedVersions {
+			k := usageKey{stepID: stepID, version: version}
+			versionUsage := StepVersionUsage{
+				Version:   version,
+				Workflows: len(workflowsByVersion[k]),
+				Files:     len(filesByVersion[k]),
+			}
+
+			if !inSteplib {
+				versionUsage.Status = StepUsageStatusUnknownStep
+			} else {
+				resolvedVersion, status, err := resolveStepUsageStatus(versions, item.LatestVersion, version)
+				if err != nil {
+					return nil, fmt.Errorf("step (%s@%s): %w", stepID, version, err)
+				}
+				versionUsage.ResolvedVersion = resolvedVersion
+				versionUsage.Status = status
+				if resolvedVersion != "" {
+					versionUsage.Deprecated = isStepVersionDeprecated(stepGroup, resolvedVersion)
+				}
+			}
+
+			item.Versions = append(item.Versions, versionUsage)
+		}
+
+		sort.Slice(item.Versions, func(i, j int) bool {
+			return compareReferencedVersions(item.Versions[i].Version, item.Versions[j].Version)
+		})
+
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Workflows != items[j].Workflows {
+			return items[i].Workflows > items[j].Workflows
+		}
+		return items[i].StepID < items[j].StepID
+	})
+
+	return items, nil
+}
+
+// resolveStepUsageStatus resolves the referenced version (the latest version if not pinned) and compares it to the latest version.
+// A reference, which is not a version (like script@1.x or script@latest), has unknown version status.
+func resolveStepUsageStatus(versions []string, latestVersion, referencedVersion string) (string, StepUsageStatus, error) {
+	resolvedVersion := latestVersion
+	if referencedVersion != "" {
+		if !pinnedVersionPattern.MatchString(referencedVersion) {
+			return "", StepUsageStatusUnknownVersion, nil
+		}
+
+		var err error
+		resolvedVersion, err = highestMatchingVersion(versions, referencedVersion)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if resolvedVersion == "" {
+		return "", StepUsageStatusUnknownVersion, nil
+	}
+	if resolvedVersion == latestVersion {
+		return resolvedVersion, StepUsageStatusUpToDate, nil
+	}
+
+	bump, err := tools.VersionBump(resolvedVersion, latestVersion)
+	if err != nil {
+		return "", "", err
+	}
+	if bump == tools.BumpTypeMajor {
+		return resolvedVersion, StepUsageStatusOutdatedMajor, nil
+	}
+	return resolvedVersion, StepUsageStatusOutdated, nil
+}
+
+// isStepVersionDeprecated tells if the version of the step is deprecated.
+// The StepLib spec has no deprecation data per version, a version is deprecated if its step is deprecated.
+func isStepVersionDeprecated(stepGroup models.StepGroupModel, version string) bool {
+	if _, ok := stepGroup.Versions[version]; !ok {
+		return false
+	}
+	return isStepDeprecated(stepGroup)
+}
+
+// compareReferencedVersions sorts the referenced versions in descending order, the unpinned references first.
+func compareReferencedVersions(a, b string) bool {
+	if a == "" || b == "" {
+		return a == "" && b != ""
+	}
+
+	aVersion, aErr := ver.NewVersion(a)
+	bVersion, bErr := ver.NewVersion(b)
+	if aErr != nil || bErr != nil {
+		return a > b
+	}
+	if aVersion.Equal(bVersion) {
+		return len(a) > len(b)
+	}
+	return bVersion.LessThan(aVersion)
+}
+
+func formatStepUsage(items []StepUsageItem, format OutputFormat) (string, error) {
+	switch format {
+	case OutputFormatJSON:
+		out, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	case OutputFormatYAML:
+		out, err := yaml.Marshal(items)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	}
+
+	header := []string{"step", "latest", "deprecated", "workflows", "files", "versions"}
+	var rows [][]string
+	for _, item := range items {
+		var versions []string
+		for _, version := range item.Versions {
+			referenced := version.Version
+			if referenced == "" {
+				referenced = "unpinned"
+			}
+			status := string(version.Status)
+			if version.Deprecated {
+				status += ", deprecated"
+			}
+			versions = append(versions, fmt.Sprintf("%s (%d workflows, %s)", referenced, version.Workflows, status))
+		}
+
+		deprecated := ""
+		if item.Deprecated {
+			deprecated = "yes"
+		}
+
+		rows = append(rows, []string{item.StepID, item.LatestVersion, deprecated, fmt.Sprint(item.Workflows), fmt.Sprint(item.Files), strings.Join(versions, ", ")})
+	}
+
+	switch format {
+	case OutputFormatCSV:
+		return formatCSVRows(header, rows)
+	case OutputFormatMarkdown:
+		return formatMarkdownRows(header, rows), nil
+	default:
+		return "", fmt.Errorf("invalid format (%s), available: [json, yaml, csv, markdown]", format)
+	}
+}
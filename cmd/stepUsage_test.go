@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const stepUsageConfig = `
+workflows:
+  primary:
+    before_run:
+    - _setup
+    after_run:
+    - _deploy
+    steps:
+    - script@1.1:
+        inputs:
+        - content: echo "test"
+    - with:
+        image: golang:1.21
+        steps:
+        - go-test@1: {}
+    - bundle::lint: {}
+  _setup:
+    before_run:
+    - primary
+    steps:
+    - activate-ssh-key@4: {}
+    - git-clone@8: {}
+  _deploy:
+    steps:
+    - https://github.com/bitrise-io/bitrise-steplib.git::deploy-to-bitrise-io@2: {}
+    - https://github.com/foo/steplib.git::deploy-to-s3@1: {}
+    - git::https://github.com/foo/steps-custom.git@main: {}
+    - path::./steps/local: {}
+    - bundle::missing: {}
+  recursive_bundle:
+    steps:
+    - bundle::recursive: {}
+step_bundles:
+  lint:
+    steps:
+    - golint: {}
+    - bundle::cache: {}
+  cache:
+    steps:
+    - save-cache@1: {}
+  recursive:
+    steps:
+    - script@1: {}
+    - bundle::recursive: {}
+`
+
+func TestBitriseConfig_WorkflowStepReferences(t *testing.T) {
+	var config bitriseConfig
+	if err := yaml.Unmarshal([]byte(stepUsageConfig), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		workflow string
+		want     []workflowStepReference
+	}{
+		{
+			workflow: "primary",
+			want: []workflowStepReference{
+				{StepID: "activate-ssh-key", Version: "4"},
+				{StepID: "git-clone", Version: "8"},
+				{StepID: "script", Version: "1.1"},
+				{StepID: "go-test", Version: "1"},
+				{StepID: "golint"},
+				{StepID: "save-cache", Version: "1"},
+				{StepID: "deploy-to-bitrise-io", Version: "2"},
+			},
+		},
+		{
+			workflow: "_setup",
+			want: []workflowStepReference{
+				{StepID: "script", Version: "1.1"},
+				{StepID: "go-test", Version: "1"},
+				{StepID: "golint"},
+				{StepID: "save-cache", Version: "1"},
+				{StepID: "deploy-to-bitrise-io", Version: "2"},
+				{StepID: "activate-ssh-key", Version: "4"},
+				{StepID: "git-clone", Version: "8"},
+			},
+		},
+		{
+			workflow: "recursive_bundle",
+			want: []workflowStepReference{
+				{StepID: "script", Version: "1"},
+			},
+		},
+		{
+			workflow: "missing",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.workflow, func(t *testing.T) {
+			if got := config.workflowStepReferences(tt.workflow); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("workflowStepReferences() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSteplibStepReference(t *testing.T) {
+	tests := []struct {
+		reference   string
+		wantStepID  string
+		wantVersion string
+		wantOk      bool
+	}{
+		{reference: "git-clone@8", wantStepID: "git-clone", wantVersion: "8", wantOk: true},
+		{reference: "git-clone", wantStepID: "git-clone", wantOk: true},
+		{reference: "script@1.x", wantStepID: "script", wantVersion: "1.x", wantOk: true},
+		{reference: "https://github.com/bitrise-io/bitrise-steplib.git::git-clone@8", wantStepID: "git-clone", wantVersion: "8", wantOk: true},
+		{reference: "https://github.com/foo/steplib.git::git-clone@8"},
+		{reference: "git::https://github.com/foo/steps-custom.git@main"},
+		{reference: "path::./steps/local"},
+		{reference: "@8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			stepID, version, ok := parseSteplibStepReference(tt.reference)
+			if stepID != tt.wantStepID || version != tt.wantVersion || ok != tt.wantOk {
+				t.Errorf("parseSteplibStepReference() = (%s, %s, %v), want (%s, %s, %v)", stepID, version, ok, tt.wantStepID, tt.wantVersion, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestResolveStepUsageStatus(t *testing.T) {
+	versions := []string{"7.1.0", "8.0.0", "8.1.0", "9.0.0-beta.1"}
+	latestVersion := "8.1.0"
+
+	tests := []struct {
+		referencedVersion string
+		wantResolved      string
+		wantStatus        StepUsageStatus
+	}{
+		{referencedVersion: "", wantResolved: "8.1.0", wantStatus: StepUsageStatusUpToDate},
+		{referencedVersion: "8", wantResolved: "8.1.0", wantStatus: StepUsageStatusUpToDate},
+		{referencedVersion: "8.0", wantResolved: "8.0.0", wantStatus: StepUsageStatusOutdated},
+		{referencedVersion: "7", wantResolved: "7.1.0", wantStatus: StepUsageStatusOutdatedMajor},
+		{referencedVersion: "9", wantStatus: StepUsageStatusUnknownVersion},
+		{referencedVersion: "8.2.0", wantStatus: StepUsageStatusUnknownVersion},
+		{referencedVersion: "1.x", wantStatus: StepUsageStatusUnknownVersion},
+		{referencedVersion: "latest", wantStatus: StepUsageStatusUnknownVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.referencedVersion, func(t *testing.T) {
+			resolved, status, err := resolveStepUsageStatus(versions, latestVersion, tt.referencedVersion)
+			if err != nil {
+				t.Fatalf("resolveStepUsageStatus() error = %v", err)
+			}
+			if resolved != tt.wantResolved || status != tt.wantStatus {
+				t.Errorf("resolveStepUsageStatus() = (%s, %s), want (%s, %s)", resolved, status, tt.wantResolved, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCompareReferencedVersions(t *testing.T) {
+	want := []string{"", "8.1", "8.0.0", "8", "7", "1.x"}
+	got := []string{"7", "8.0.0", "1.x", "8", "", "8.1"}
+
+	sort.Slice(got, func(i, j int) bool { return compareReferencedVersions(got[i], got[j]) })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareReferencedVersions() order = %v, want %v", got, want)
+	}
+}
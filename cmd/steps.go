@@ -1,15 +1,18 @@
 package cmd
 
 import (
-	"bytes"
+	"fmt"
 	"os"
+	"regexp"
 	"slices"
+	"sort"
 	"strings"
-	"text/template"
+	"time"
 
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/stepman/models"
 	"github.com/godrei/stepper/tools"
+	ver "github.com/hashicorp/go-version"
 	giturl "github.com/kubescape/go-git-url"
 	"github.com/spf13/cobra"
 )
@@ -28,11 +31,49 @@ var bitriseStepsCmd = &cobra.Command{
 			repoURLFilters = strings.Split(repoURLFilterFlag, ",")
 		}
 
+		repoURLPatterns, err := compileRegexps(repoURLRegexFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
+		var excludedRepoURLFilters []string
+		if excludeRepoURLFilterFlag != "" {
+			excludedRepoURLFilters = strings.Split(excludeRepoURLFilterFlag, ",")
+		}
+
+		excludedRepoURLPatterns, err := compileRegexps(excludeRepoURLRegexFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
+		var where *StepFilter
+		if whereFlag != "" {
+			filter, err := ParseStepFilter(whereFlag)
+			if err != nil {
+				logger.Errorf(err.Error())
+				os.Exit(1)
+			}
+			where = &filter
+		}
+
 		printTemplate := printTemplateFlag
 		if printTemplate == "" {
 			printTemplate = defaultPrintTemplate
 		}
 
+		format, err := parseOutputFormat(formatFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
+		var columns []string
+		if columnsFlag != "" {
+			columns = strings.Split(columnsFlag, ",")
+		}
+
 		ignoreDeprecatedSteps := ignoreDeprecatedStepsFlag
 
 		var projectTypes []string
@@ -45,12 +86,39 @@ var bitriseStepsCmd = &cobra.Command{
 			toolkits = strings.Split(toolkitFlag, ",")
 		}
 
+		versionsMode, err := parseVersionsMode(versionsFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
+		sortBy, err := parseSortKey(sortFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
+		groupBy, err := parseGroupBy(groupByFlag)
+		if err != nil {
+			logger.Errorf(err.Error())
+			os.Exit(1)
+		}
+
 		if err := stepLister.ListSteps(ListOptions{
-			RepoURLFilters:        repoURLFilters,
-			IgnoreDeprecatedSteps: ignoreDeprecatedSteps,
-			AllowedProjectTypes:   projectTypes,
-			AllowedToolkits:       toolkits,
-			PrintTemplate:         printTemplate,
+			RepoURLFilters:          repoURLFilters,
+			RepoURLPatterns:         repoURLPatterns,
+			ExcludedRepoURLFilters:  excludedRepoURLFilters,
+			ExcludedRepoURLPatterns: excludedRepoURLPatterns,
+			Where:                   where,
+			Versions:                versionsMode,
+			SortBy:                  sortBy,
+			GroupBy:                 groupBy,
+			IgnoreDeprecatedSteps:   ignoreDeprecatedSteps,
+			AllowedProjectTypes:     projectTypes,
+			AllowedToolkits:         toolkits,
+			PrintTemplate:           printTemplate,
+			Format:                  format,
+			Columns:                 columns,
 		}); err != nil {
 			logger.Errorf(err.Error())
 			os.Exit(1)
@@ -64,6 +132,15 @@ var (
 	printTemplateFlag         string
 	projectTypesFilterFlag    string
 	toolkitFlag               string
+	formatFlag                string
+	columnsFlag               string
+	repoURLRegexFlag          []string
+	excludeRepoURLFilterFlag  string
+	excludeRepoURLRegexFlag   []string
+	whereFlag                 string
+	versionsFlag              string
+	sortFlag                  string
+	groupByFlag               string
 )
 
 func init() {
@@ -74,6 +151,15 @@ func init() {
 	bitriseStepsCmd.Flags().StringVarP(&printTemplateFlag, "print-template", "", "", "Template for printing the list of steps. The template is executed on the '[]Step' list.")
 	bitriseStepsCmd.Flags().StringVarP(&projectTypesFilterFlag, "project-types", "", "", "Filter steps by project types")
 	bitriseStepsCmd.Flags().StringVarP(&toolkitFlag, "toolkits", "", "", "Filter steps by toolkits [go,bash]")
+	bitriseStepsCmd.Flags().StringArrayVarP(&repoURLRegexFlag, "repo-url-regex", "", nil, "Regex, which the repository URL should match. Can be specified multiple times, a step is listed if any of them matches.")
+	bitriseStepsCmd.Flags().StringVarP(&excludeRepoURLFilterFlag, "exclude-repo-url-filter", "", "", "List of repo URL filters, separated by a comma character. Steps with repository URL containing any of them are not listed.")
+	bitriseStepsCmd.Flags().StringArrayVarP(&excludeRepoURLRegexFlag, "exclude-repo-url-regex", "", nil, "Regex, which the repository URL should not match. Can be specified multiple times.")
+	bitriseStepsCmd.Flags().StringVarP(&whereFlag, "where", "", "", `Filter expression evaluated against every listed step version, for example: 'toolkit == "go" && "ios" in project_type_tags && has_input("verbose_log") && published_after("2024-01-01")'.`)
+	bitriseStepsCmd.Flags().StringVarP(&versionsFlag, "versions", "", string(VersionsModeLatest), "Which versions of a step to list [latest,all,major-latest]. 'major-latest' lists the latest version of every major version.")
+	bitriseStepsCmd.Flags().StringVarP(&sortFlag, "sort", "", string(SortKeyID), "Sort the list by [id,published,repo]. 'published' lists the most recently published versions first.")
+	bitriseStepsCmd.Flags().StringVarP(&groupByFlag, "group-by", "", string(GroupByNone), "Group the list by [none,repo]. 'repo' lists only the first step (in the sort order) of every repository.")
+	bitriseStepsCmd.Flags().StringVarP(&formatFlag, "format", "", string(OutputFormatTemplate), "Output format [template,json,yaml,csv,markdown]. The 'template' format uses the --print-template flag.")
+	bitriseStepsCmd.Flags().StringVarP(&columnsFlag, "columns", "", "", "List of columns for the csv and markdown formats, separated by a comma character. Default: id,version,owner,repo.")
 }
 
 type StepLister struct {
@@ -81,11 +167,78 @@ type StepLister struct {
 }
 
 type ListOptions struct {
-	RepoURLFilters        []string
-	IgnoreDeprecatedSteps bool
-	AllowedProjectTypes   []string
-	AllowedToolkits       []string
-	PrintTemplate         string
+	RepoURLFilters          []string
+	RepoURLPatterns         []*regexp.Regexp
+	ExcludedRepoURLFilters  []string
+	ExcludedRepoURLPatterns []*regexp.Regexp
+	Where                   *StepFilter
+	Versions                VersionsMode
+	SortBy                  SortKey
+	GroupBy                 GroupBy
+	IgnoreDeprecatedSteps   bool
+	AllowedProjectTypes     []string
+	AllowedToolkits         []string
+	PrintTemplate           string
+	Format                  OutputFormat
+	Columns                 []string
+}
+
+// VersionsMode ...
+type VersionsMode string
+
+const (
+	// VersionsModeLatest ...
+	VersionsModeLatest VersionsMode = "latest"
+	// VersionsModeAll ...
+	VersionsModeAll VersionsMode = "all"
+	// VersionsModeMajorLatest ...
+	VersionsModeMajorLatest VersionsMode = "major-latest"
+)
+
+func parseVersionsMode(mode string) (VersionsMode, error) {
+	switch VersionsMode(mode) {
+	case VersionsModeLatest, VersionsModeAll, VersionsModeMajorLatest:
+		return VersionsMode(mode), nil
+	}
+	return "", fmt.Errorf("invalid versions (%s), available: [latest, all, major-latest]", mode)
+}
+
+// SortKey ...
+type SortKey string
+
+const (
+	// SortKeyID ...
+	SortKeyID SortKey = "id"
+	// SortKeyPublished ...
+	SortKeyPublished SortKey = "published"
+	// SortKeyRepo ...
+	SortKeyRepo SortKey = "repo"
+)
+
+func parseSortKey(key string) (SortKey, error) {
+	switch SortKey(key) {
+	case SortKeyID, SortKeyPublished, SortKeyRepo:
+		return SortKey(key), nil
+	}
+	return "", fmt.Errorf("invalid sort (%s), available: [id, published, repo]", key)
+}
+
+// GroupBy ...
+type GroupBy string
+
+const (
+	// GroupByNone ...
+	GroupByNone GroupBy = "none"
+	// GroupByRepo ...
+	GroupByRepo GroupBy = "repo"
+)
+
+func parseGroupBy(groupBy string) (GroupBy, error) {
+	switch GroupBy(groupBy) {
+	case GroupByNone, GroupByRepo:
+		return GroupBy(groupBy), nil
+	}
+	return "", fmt.Errorf("invalid group-by (%s), available: [none, repo]", groupBy)
 }
 
 type StepRepository struct {
@@ -96,7 +249,15 @@ type StepRepository struct {
 
 type Step struct {
 	models.StepModel
-	StepID     string
+	StepID string
+	// Version is the version of the listed step.
+	Version string
+	// AllVersions lists every version of the step in descending order.
+	AllVersions []string
+	// LatestVersion is true if Version is the latest version of the step.
+	LatestVersion bool
+	// Deprecated is true if the step has deprecation notes or a removal date.
+	Deprecated bool
 	Repository StepRepository
 }
 
@@ -111,7 +272,7 @@ func (l StepLister) ListSteps(opts ListOptions) error {
 		return err
 	}
 
-	if err := l.printSteps(steps, opts.PrintTemplate); err != nil {
+	if err := l.printSteps(steps, opts); err != nil {
 		return err
 	}
 
@@ -119,11 +280,12 @@ func (l StepLister) ListSteps(opts ListOptions) error {
 }
 
 func (l StepLister) getStpLibSpec() (models.StepCollectionModel, error) {
-	if err := tools.StepmanUpdate(defaultSteplibURI); err != nil {
+	source, err := steplibSource()
+	if err != nil {
 		return models.StepCollectionModel{}, err
 	}
 
-	steplib, err := tools.StepmanExportSpec(defaultSteplibURI, tools.ExportTypesLatest)
+	steplib, err := source.Spec(tools.ExportTypesFull)
 	if err != nil {
 		return models.StepCollectionModel{}, err
 	}
@@ -134,7 +296,28 @@ func (l StepLister) getStpLibSpec() (models.StepCollectionModel, error) {
 func (l StepLister) listSteps(steplib models.StepCollectionModel, opts ListOptions) ([]Step, error) {
 	var steps []Step
 	for stepID, stepGroup := range steplib.Steps {
-		for _, step := range stepGroup.Versions {
+		latestVersion, err := tools.LatestVersionNumber(stepGroup)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", stepID, err)
+		}
+
+		var versions []string
+		for version := range stepGroup.Versions {
+			versions = append(versions, version)
+		}
+		allVersions, err := tools.SortVersionsDesc(versions)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", stepID, err)
+		}
+
+		listedVersions, err := selectVersions(allVersions, latestVersion, opts.Versions)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", stepID, err)
+		}
+
+		for _, version := range listedVersions {
+			step := stepGroup.Versions[version]
+
 			if step.Source == nil {
 				l.logger.Warnf("step without source: %s", stepID)
 				continue
@@ -188,38 +371,200 @@ func (l StepLister) listSteps(steplib models.StepCollectionModel, opts ListOptio
 				}
 			}
 
+			if len(opts.RepoURLPatterns) > 0 {
+				matches := false
+				for _, pattern := range opts.RepoURLPatterns {
+					if pattern.MatchString(step.Source.Git) {
+						matches = true
+						break
+					}
+				}
+				if !matches {
+					continue
+				}
+			}
+
+			excluded := false
+			for _, filter := range opts.ExcludedRepoURLFilters {
+				if strings.Contains(step.Source.Git, filter) {
+					excluded = true
+					break
+				}
+			}
+			for _, pattern := range opts.ExcludedRepoURLPatterns {
+				if pattern.MatchString(step.Source.Git) {
+					excluded = true
+					break
+				}
+			}
+			if excluded {
+				continue
+			}
+
 			gitURL, err := giturl.NewGitURL(step.Source.Git)
 			if err != nil {
 				return nil, err
 			}
 
-			steps = append(steps, Step{
-				StepModel: models.StepModel{},
-				StepID:    stepID,
+			listedStep := Step{
+				StepModel:     step,
+				StepID:        stepID,
+				Version:       version,
+				AllVersions:   allVersions,
+				LatestVersion: version == latestVersion,
+				Deprecated:    isDeprecated,
 				Repository: StepRepository{
 					Host:  gitURL.GetHostName(),
 					Owner: gitURL.GetOwnerName(),
 					Repo:  gitURL.GetRepoName(),
 				},
-			})
+			}
+
+			if opts.Where != nil {
+				record, err := newStepRecord(listedStep)
+				if err != nil {
+					return nil, fmt.Errorf("step (%s): %w", stepID, err)
+				}
+
+				match, err := opts.Where.Match(record)
+				if err != nil {
+					return nil, fmt.Errorf("step (%s@%s): %w", stepID, version, err)
+				}
+				if !match {
+					continue
+				}
+			}
+
+			steps = append(steps, listedStep)
 		}
 	}
 
+	if err := sortSteps(steps, opts.SortBy); err != nil {
+		return nil, err
+	}
+
+	if opts.GroupBy == GroupByRepo {
+		steps = groupStepsByRepo(steps)
+	}
+
 	return steps, nil
 }
 
-func (l StepLister) printSteps(steps []Step, tmpl string) error {
-	t := template.New("steps")
-	t, err := t.Parse(tmpl)
+func (l StepLister) printSteps(steps []Step, opts ListOptions) error {
+	format := opts.Format
+	if format == "" {
+		format = OutputFormatTemplate
+	}
+
+	out, err := formatSteps(steps, format, opts.PrintTemplate, opts.Columns)
 	if err != nil {
 		return err
 	}
 
-	var buff bytes.Buffer
-	if err := t.Execute(&buff, steps); err != nil {
-		return err
+	l.logger.Printf("%s", out)
+	return nil
+}
+
+func compileRegexps(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex (%s): %w", pattern, err)
+		}
+		compiled = append(compiled, re)
 	}
+	return compiled, nil
+}
+
+// selectVersions returns the versions to list from the descending ordered versions of a step.
+func selectVersions(allVersions []string, latestVersion string, mode VersionsMode) ([]string, error) {
+	switch mode {
+	case VersionsModeAll:
+		return allVersions, nil
+	case VersionsModeMajorLatest:
+		var versions []string
+		seenMajors := map[int64]bool{}
+		for _, version := range allVersions {
+			v, err := ver.NewVersion(version)
+			if err != nil {
+				return nil, err
+			}
+
+			major := v.Segments64()[0]
+			if seenMajors[major] {
+				continue
+			}
+			seenMajors[major] = true
+			versions = append(versions, version)
+		}
+		return versions, nil
+	default:
+		return []string{latestVersion}, nil
+	}
+}
+
+func sortSteps(steps []Step, sortBy SortKey) error {
+	versions := map[string]*ver.Version{}
+	for _, step := range steps {
+		v, err := ver.NewVersion(step.Version)
+		if err != nil {
+			return fmt.Errorf("step (%s): %w", step.StepID, err)
+		}
+		versions[step.StepID+"@"+step.Version] = v
+	}
+
+	byIDAndVersion := func(a, b Step) bool {
+		if a.StepID != b.StepID {
+			return a.StepID < b.StepID
+		}
+		return versions[b.StepID+"@"+b.Version].LessThan(versions[a.StepID+"@"+a.Version])
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		a, b := steps[i], steps[j]
+
+		switch sortBy {
+		case SortKeyPublished:
+			aPublishedAt, bPublishedAt := publishedAtOrZero(a), publishedAtOrZero(b)
+			if !aPublishedAt.Equal(bPublishedAt) {
+				return aPublishedAt.After(bPublishedAt)
+			}
+		case SortKeyRepo:
+			aRepo, bRepo := repositoryKey(a.Repository), repositoryKey(b.Repository)
+			if aRepo != bRepo {
+				return aRepo < bRepo
+			}
+		}
+
+		return byIDAndVersion(a, b)
+	})
 
-	l.logger.Printf(buff.String())
 	return nil
 }
+
+// groupStepsByRepo keeps only the first step of every repository.
+func groupStepsByRepo(steps []Step) []Step {
+	var grouped []Step
+	seenRepos := map[string]bool{}
+	for _, step := range steps {
+		key := repositoryKey(step.Repository)
+		if seenRepos[key] {
+			continue
+		}
+		seenRepos[key] = true
+		grouped = append(grouped, step)
+	}
+	return grouped
+}
+
+func repositoryKey(repository StepRepository) string {
+	return strings.ToLower(repository.Host + "/" + repository.Owner + "/" + repository.Repo)
+}
+
+func publishedAtOrZero(step Step) time.Time {
+	if step.PublishedAt == nil {
+		return time.Time{}
+	}
+	return *step.PublishedAt
+}
@@ -0,0 +1,612 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	ver "github.com/hashicorp/go-version"
+)
+
+// StepFilter is a compiled '--where' expression, evaluated against every listed step version.
+//
+// Grammar:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | comparison
+//	comparison = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "=~" | "!~" | "in" ) operand ]
+//	operand    = string | number | "true" | "false" | field | function "(" [ expr { "," expr } ] ")" | "(" expr ")"
+//
+// Fields are the fields of the StepRecord (id, version, toolkit, project_type_tags, inputs, deprecated, ...).
+// The right operand of the '=~' and '!~' operators is a regex string, compiled when the expression is parsed.
+type StepFilter struct {
+	expression string
+	eval       evalFunc
+}
+
+type evalFunc func(fields map[string]interface{}) (interface{}, error)
+
+type filterFunction struct {
+	args int
+	call func(fields map[string]interface{}, args []interface{}) (interface{}, error)
+}
+
+var filterFunctions = map[string]filterFunction{
+	"has_input": {args: 1, call: func(fields map[string]interface{}, args []interface{}) (interface{}, error) {
+		return listContains(fields["inputs"], args[0])
+	}},
+	"has_output": {args: 1, call: func(fields map[string]interface{}, args []interface{}) (interface{}, error) {
+		return listContains(fields["outputs"], args[0])
+	}},
+	"published_after": {args: 1, call: func(fields map[string]interface{}, args []interface{}) (interface{}, error) {
+		return comparePublishedAt(fields, args[0], func(publishedAt, t time.Time) bool { return publishedAt.After(t) })
+	}},
+	"published_before": {args: 1, call: func(fields map[string]interface{}, args []interface{}) (interface{}, error) {
+		return comparePublishedAt(fields, args[0], func(publishedAt, t time.Time) bool { return publishedAt.Before(t) })
+	}},
+}
+
+// ParseStepFilter compiles the given filter expression.
+func ParseStepFilter(expression string) (StepFilter, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return StepFilter{}, fmt.Errorf("invalid filter (%s): %w", expression, err)
+	}
+
+	p := filterParser{tokens: tokens}
+	eval, err := p.parseOr()
+	if err != nil {
+		return StepFilter{}, fmt.Errorf("invalid filter (%s): %w", expression, err)
+	}
+	if !p.done() {
+		return StepFilter{}, fmt.Errorf("invalid filter (%s): unexpected %s", expression, p.peek().value)
+	}
+
+	return StepFilter{expression: expression, eval: eval}, nil
+}
+
+// Match ...
+func (f StepFilter) Match(record StepRecord) (bool, error) {
+	value, err := f.eval(stepRecordFields(record))
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate filter (%s): %w", f.expression, err)
+	}
+
+	match, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("filter (%s) does not evaluate to a boolean value", f.expression)
+	}
+	return match, nil
+}
+
+func stepRecordFields(record StepRecord) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                record.ID,
+		"version":           record.Version,
+		"latest_version":    record.LatestVersion,
+		"all_versions":      record.AllVersions,
+		"title":             record.Title,
+		"summary":           record.Summary,
+		"published_at":      formatTime(record.PublishedAt),
+		"source":            record.Source,
+		"host":              record.Host,
+		"owner":             record.Owner,
+		"repo":              record.Repo,
+		"toolkit":           record.Toolkit,
+		"project_type_tags": record.ProjectTypeTags,
+		"type_tags":         record.TypeTags,
+		"inputs":            record.Inputs,
+		"outputs":           record.Outputs,
+		"deprecated":        record.Deprecated,
+	}
+}
+
+// Tokenizer
+
+type filterTokenKind int
+
+const (
+	tokenString filterTokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	value string
+}
+
+var filterOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")", ","}
+
+func tokenizeFilter(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, value: value.String()})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: tokenNumber, value: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: tokenIdent, value: string(runes[i:j])})
+			i = j
+		default:
+			found := false
+			for _, operator := range filterOperators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, filterToken{kind: tokenOperator, value: operator})
+					i += len([]rune(operator))
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// Parser
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.done() {
+		return filterToken{kind: tokenOperator, value: "end of filter"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) acceptOperator(operator string) bool {
+	token := p.peek()
+	if !p.done() && token.kind == tokenOperator && token.value == operator {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expectOperator(operator string) error {
+	if !p.acceptOperator(operator) {
+		return fmt.Errorf("expected '%s', got '%s'", operator, p.peek().value)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (evalFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptOperator("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalOperation(left, right, true)
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (evalFunc, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptOperator("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalOperation(left, right, false)
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (evalFunc, error) {
+	if p.acceptOperator("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(fields map[string]interface{}) (interface{}, error) {
+			value, err := operand(fields)
+			if err != nil {
+				return nil, err
+			}
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("'!' operator requires a boolean operand")
+			}
+			return !b, nil
+		}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (evalFunc, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	operator := ""
+	switch {
+	case p.done():
+	case token.kind == tokenOperator && strings.Contains(" == != < <= > >= =~ !~ ", " "+token.value+" "):
+		operator = token.value
+	case token.kind == tokenIdent && token.value == "in":
+		operator = token.value
+	}
+	if operator == "" {
+		return left, nil
+	}
+	p.pos++
+
+	if operator == "=~" || operator == "!~" {
+		return p.parseRegexMatch(operator, left)
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(fields map[string]interface{}) (interface{}, error) {
+		leftValue, err := left(fields)
+		if err != nil {
+			return nil, err
+		}
+		rightValue, err := right(fields)
+		if err != nil {
+			return nil, err
+		}
+		return compareValues(operator, leftValue, rightValue)
+	}, nil
+}
+
+// parseRegexMatch compiles the regex right operand of the '=~' and '!~' operators once, when the expression is parsed.
+func (p *filterParser) parseRegexMatch(operator string, left evalFunc) (evalFunc, error) {
+	if p.peek().kind != tokenString {
+		return nil, fmt.Errorf("'%s' operator requires a regex string right operand", operator)
+	}
+	pattern := p.peek().value
+	p.pos++
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex (%s): %w", pattern, err)
+	}
+
+	return func(fields map[string]interface{}) (interface{}, error) {
+		leftValue, err := left(fields)
+		if err != nil {
+			return nil, err
+		}
+		return matchRegex(operator, re, leftValue)
+	}, nil
+}
+
+func (p *filterParser) parseOperand() (evalFunc, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case tokenString:
+		return constant(token.value), nil
+	case tokenNumber:
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", token.value)
+		}
+		return constant(number), nil
+	case tokenIdent:
+		switch token.value {
+		case "true":
+			return constant(true), nil
+		case "false":
+			return constant(false), nil
+		}
+
+		if p.acceptOperator("(") {
+			return p.parseFunctionCall(token.value)
+		}
+
+		if _, ok := stepRecordFields(StepRecord{})[token.value]; !ok {
+			return nil, fmt.Errorf("unknown field: %s", token.value)
+		}
+		field := token.value
+		return func(fields map[string]interface{}) (interface{}, error) {
+			return fields[field], nil
+		}, nil
+	case tokenOperator:
+		if token.value == "(" {
+			expr, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOperator(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected '%s'", token.value)
+}
+
+func (p *filterParser) parseFunctionCall(name string) (evalFunc, error) {
+	function, ok := filterFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function: %s", name)
+	}
+
+	var args []evalFunc
+	if !p.acceptOperator(")") {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.acceptOperator(")") {
+				break
+			}
+			if err := p.expectOperator(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(args) != function.args {
+		return nil, fmt.Errorf("%s expects %d argument(s), got %d", name, function.args, len(args))
+	}
+
+	return func(fields map[string]interface{}) (interface{}, error) {
+		var values []interface{}
+		for _, arg := range args {
+			value, err := arg(fields)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return function.call(fields, values)
+	}, nil
+}
+
+// Evaluation
+
+func constant(value interface{}) evalFunc {
+	return func(map[string]interface{}) (interface{}, error) {
+		return value, nil
+	}
+}
+
+func logicalOperation(left, right evalFunc, isOr bool) evalFunc {
+	return func(fields map[string]interface{}) (interface{}, error) {
+		leftValue, err := left(fields)
+		if err != nil {
+			return nil, err
+		}
+		leftBool, ok := leftValue.(bool)
+		if !ok {
+			return nil, fmt.Errorf("logical operators require boolean operands")
+		}
+		if leftBool == isOr {
+			return leftBool, nil
+		}
+
+		rightValue, err := right(fields)
+		if err != nil {
+			return nil, err
+		}
+		rightBool, ok := rightValue.(bool)
+		if !ok {
+			return nil, fmt.Errorf("logical operators require boolean operands")
+		}
+		return rightBool, nil
+	}
+}
+
+func matchRegex(operator string, re *regexp.Regexp, left interface{}) (interface{}, error) {
+	var match bool
+	switch l := left.(type) {
+	case string:
+		match = re.MatchString(l)
+	case []string:
+		for _, item := range l {
+			if re.MatchString(item) {
+				match = true
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("'%s' operator requires a string or list left operand", operator)
+	}
+
+	if operator == "!~" {
+		return !match, nil
+	}
+	return match, nil
+}
+
+func compareValues(operator string, left, right interface{}) (interface{}, error) {
+	switch operator {
+	case "in":
+		switch r := right.(type) {
+		case []string:
+			return listContains(r, left)
+		case string:
+			l, ok := left.(string)
+			if !ok {
+				return nil, fmt.Errorf("'in' operator requires a string left operand")
+			}
+			return strings.Contains(r, l), nil
+		default:
+			return nil, fmt.Errorf("'in' operator requires a list or string right operand")
+		}
+	case "==", "!=":
+		if _, ok := left.([]string); ok {
+			return nil, fmt.Errorf("'%s' operator can not compare lists, use the 'in' operator", operator)
+		}
+		equal := fmt.Sprint(left) == fmt.Sprint(right)
+		if operator == "!=" {
+			return !equal, nil
+		}
+		return equal, nil
+	default:
+		order, err := orderValues(left, right)
+		if err != nil {
+			return nil, err
+		}
+
+		switch operator {
+		case "<":
+			return order < 0, nil
+		case "<=":
+			return order <= 0, nil
+		case ">":
+			return order > 0, nil
+		case ">=":
+			return order >= 0, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operator: %s", operator)
+}
+
+// orderValues compares numbers numerically, semantic versions by precedence and any other strings lexicographically.
+func orderValues(left, right interface{}) (int, error) {
+	if l, ok := left.(float64); ok {
+		r, ok := right.(float64)
+		if !ok {
+			return 0, fmt.Errorf("can not compare a number to %v", right)
+		}
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return 0, fmt.Errorf("can not compare %v to %v", left, right)
+	}
+
+	lVersion, lErr := ver.NewVersion(l)
+	rVersion, rErr := ver.NewVersion(r)
+	if lErr == nil && rErr == nil {
+		return lVersion.Compare(rVersion), nil
+	}
+
+	return strings.Compare(l, r), nil
+}
+
+func listContains(list, item interface{}) (bool, error) {
+	items, ok := list.([]string)
+	if !ok && list != nil {
+		return false, fmt.Errorf("%v is not a list", list)
+	}
+	s, ok := item.(string)
+	if !ok {
+		return false, fmt.Errorf("%v is not a string", item)
+	}
+
+	for _, i := range items {
+		if i == s {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func comparePublishedAt(fields map[string]interface{}, date interface{}, compare func(publishedAt, t time.Time) bool) (bool, error) {
+	dateStr, ok := date.(string)
+	if !ok {
+		return false, fmt.Errorf("%v is not a date", date)
+	}
+	t, err := parseFilterDate(dateStr)
+	if err != nil {
+		return false, err
+	}
+
+	publishedAtStr, _ := fields["published_at"].(string)
+	if publishedAtStr == "" {
+		return false, nil
+	}
+	publishedAt, err := time.Parse(time.RFC3339, publishedAtStr)
+	if err != nil {
+		return false, err
+	}
+
+	return compare(publishedAt, t), nil
+}
+
+func parseFilterDate(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date (%s), expected format: 2006-01-02 or RFC3339", date)
+	}
+	return t, nil
+}
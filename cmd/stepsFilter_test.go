@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepFilter_Match(t *testing.T) {
+	publishedAt := time.Date(2024, 2, 10, 10, 0, 0, 0, time.UTC)
+	record := StepRecord{
+		ID:              "git-clone",
+		Version:         "8.1.0",
+		LatestVersion:   true,
+		AllVersions:     []string{"8.1.0", "8.0.0"},
+		Title:           "Git Clone Repository",
+		PublishedAt:     &publishedAt,
+		Host:            "github.com",
+		Owner:           "bitrise-steplib",
+		Repo:            "steps-git-clone",
+		Toolkit:         "go",
+		ProjectTypeTags: []string{"ios", "android"},
+		Inputs:          []string{"repository_url", "clone_into_dir"},
+		Outputs:         []string{"GIT_CLONE_COMMIT_HASH"},
+	}
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{expression: `id == "git-clone"`, want: true},
+		{expression: `id != 'git-clone'`, want: false},
+		{expression: `toolkit == "go" && latest_version`, want: true},
+		{expression: `toolkit == "bash" || deprecated`, want: false},
+		{expression: `!deprecated && (toolkit == "bash" || owner == "bitrise-steplib")`, want: true},
+		{expression: `version >= "8.0.0" && version < "8.10.0"`, want: true},
+		{expression: `version > "8.1"`, want: false},
+		{expression: `"ios" in project_type_tags`, want: true},
+		{expression: `"Clone" in title`, want: true},
+		{expression: `id =~ "^git-"`, want: true},
+		{expression: `id !~ "^git-"`, want: false},
+		{expression: `inputs =~ "_dir$"`, want: true},
+		{expression: `has_input("repository_url") && !has_output("GIT_CLONE_COMMIT_AUTHOR_NAME")`, want: true},
+		{expression: `published_after("2024-01-01") && published_before("2024-02-10T11:00:00Z")`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			filter, err := ParseStepFilter(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseStepFilter() error = %v", err)
+			}
+
+			got, err := filter.Match(record)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStepFilter_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{name: "unknown field", expression: `name == "git-clone"`},
+		{name: "unknown function", expression: `has_step("git-clone")`},
+		{name: "wrong argument count", expression: `has_input("a", "b")`},
+		{name: "unterminated string", expression: `id == "git-clone`},
+		{name: "missing closing parenthesis", expression: `(id == "git-clone"`},
+		{name: "trailing token", expression: `id == "git-clone" "script"`},
+		{name: "unexpected character", expression: `id == "git-clone" ; deprecated`},
+		{name: "missing operand", expression: `id ==`},
+		{name: "invalid regex", expression: `id =~ "("`},
+		{name: "regex operand not a string", expression: `id =~ title`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStepFilter(tt.expression)
+			if err == nil {
+				t.Fatalf("ParseStepFilter(%s) expected an error", tt.expression)
+			}
+			if !strings.HasPrefix(err.Error(), "invalid filter ("+tt.expression+"): ") {
+				t.Errorf("ParseStepFilter() error = %v, want the invalid filter prefix", err)
+			}
+		})
+	}
+}
+
+func TestStepFilter_MatchErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{name: "not a boolean", expression: `id`},
+		{name: "logical operator on string", expression: `id && deprecated`},
+		{name: "list equality", expression: `inputs == "repository_url"`},
+		{name: "number compared to string", expression: `1 < id`},
+		{name: "invalid date", expression: `published_after("yesterday")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseStepFilter(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseStepFilter() error = %v", err)
+			}
+			if _, err := filter.Match(StepRecord{ID: "git-clone"}); err == nil {
+				t.Errorf("Match() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/godrei/stepper/tools"
+	"gopkg.in/yaml.v2"
+)
+
+// OutputFormat ...
+type OutputFormat string
+
+const (
+	// OutputFormatTemplate ...
+	OutputFormatTemplate OutputFormat = "template"
+	// OutputFormatJSON ...
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatYAML ...
+	OutputFormatYAML OutputFormat = "yaml"
+	// OutputFormatCSV ...
+	OutputFormatCSV OutputFormat = "csv"
+	// OutputFormatMarkdown ...
+	OutputFormatMarkdown OutputFormat = "markdown"
+)
+
+var outputFormats = []OutputFormat{OutputFormatTemplate, OutputFormatJSON, OutputFormatYAML, OutputFormatCSV, OutputFormatMarkdown}
+
+func parseOutputFormat(format string) (OutputFormat, error) {
+	for _, outputFormat := range outputFormats {
+		if string(outputFormat) == format {
+			return outputFormat, nil
+		}
+	}
+	return "", fmt.Errorf("invalid format (%s), available: %v", format, outputFormats)
+}
+
+// StepRecord is the representation of a listed step in the structured output formats.
+// The field names are part of the command's interface, do not rename them.
+type StepRecord struct {
+	ID              string     `json:"id" yaml:"id"`
+	Version         string     `json:"version" yaml:"version"`
+	LatestVersion   bool       `json:"latest_version" yaml:"latest_version"`
+	Deprecated      bool       `json:"deprecated" yaml:"deprecated"`
+	AllVersions     []string   `json:"all_versions" yaml:"all_versions"`
+	Title           string     `json:"title" yaml:"title"`
+	Summary         string     `json:"summary" yaml:"summary"`
+	PublishedAt     *time.Time `json:"published_at,omitempty" yaml:"published_at,omitempty"`
+	Source          string     `json:"source" yaml:"source"`
+	Host            string     `json:"host" yaml:"host"`
+	Owner           string     `json:"owner" yaml:"owner"`
+	Repo            string     `json:"repo" yaml:"repo"`
+	Toolkit         string     `json:"toolkit" yaml:"toolkit"`
+	ProjectTypeTags []string   `json:"project_type_tags" yaml:"project_type_tags"`
+	TypeTags        []string   `json:"type_tags" yaml:"type_tags"`
+	Inputs          []string   `json:"inputs" yaml:"inputs"`
+	Outputs         []string   `json:"outputs" yaml:"outputs"`
+}
+
+func newStepRecord(step Step) (StepRecord, error) {
+	inputs, err := envKeys(step.Inputs)
+	if err != nil {
+		return StepRecord{}, err
+	}
+	outputs, err := envKeys(step.Outputs)
+	if err != nil {
+		return StepRecord{}, err
+	}
+
+	source := ""
+	if step.Source != nil {
+		source = step.Source.Git
+	}
+
+	return StepRecord{
+		ID:              step.StepID,
+		Version:         step.Version,
+		LatestVersion:   step.LatestVersion,
+		Deprecated:      step.Deprecated,
+		AllVersions:     step.AllVersions,
+		Title:           tools.StringValue(step.Title),
+		Summary:         tools.StringValue(step.Summary),
+		PublishedAt:     step.PublishedAt,
+		Source:          source,
+		Host:            step.Repository.Host,
+		Owner:           step.Repository.Owner,
+		Repo:            step.Repository.Repo,
+		Toolkit:         tools.ToolkitName(step.Toolkit),
+		ProjectTypeTags: step.ProjectTypeTags,
+		TypeTags:        step.TypeTags,
+		Inputs:          inputs,
+		Outputs:         outputs,
+	}, nil
+}
+
+var defaultColumns = []string{"id", "version", "owner", "repo"}
+
+var stepColumns = map[string]func(record StepRecord) string{
+	"id":                func(r StepRecord) string { return r.ID },
+	"version":           func(r StepRecord) string { return r.Version },
+	"latest_version":    func(r StepRecord) string { return fmt.Sprintf("%t", r.LatestVersion) },
+	"deprecated":        func(r StepRecord) string { return fmt.Sprintf("%t", r.Deprecated) },
+	"all_versions":      func(r StepRecord) string { return strings.Join(r.AllVersions, " ") },
+	"title":             func(r StepRecord) string { return r.Title },
+	"summary":           func(r StepRecord) string { return r.Summary },
+	"published_at":      func(r StepRecord) string { return formatTime(r.PublishedAt) },
+	"source":            func(r StepRecord) string { return r.Source },
+	"host":              func(r StepRecord) string { return r.Host },
+	"owner":             func(r StepRecord) string { return r.Owner },
+	"repo":              func(r StepRecord) string { return r.Repo },
+	"toolkit":           func(r StepRecord) string { return r.Toolkit },
+	"project_type_tags": func(r StepRecord) string { return strings.Join(r.ProjectTypeTags, " ") },
+	"type_tags":         func(r StepRecord) string { return strings.Join(r.TypeTags, " ") },
+	"inputs":            func(r StepRecord) string { return strings.Join(r.Inputs, " ") },
+	"outputs":           func(r StepRecord) string { return strings.Join(r.Outputs, " ") },
+}
+
+func validateColumns(columns []string) error {
+	for _, column := range columns {
+		if _, ok := stepColumns[column]; !ok {
+			return fmt.Errorf("unknown column: %s", column)
+		}
+	}
+	return nil
+}
+
+func formatSteps(steps []Step, format OutputFormat, tmpl string, columns []string) (string, error) {
+	if format == OutputFormatTemplate {
+		return executeStepsTemplate(steps, tmpl)
+	}
+
+	if len(columns) == 0 {
+		columns = defaultColumns
+	}
+	if err := validateColumns(columns); err != nil {
+		return "", err
+	}
+
+	records := make([]StepRecord, 0, len(steps))
+	for _, step := range steps {
+		record, err := newStepRecord(step)
+		if err != nil {
+			return "", fmt.Errorf("step (%s): %w", step.StepID, err)
+		}
+		records = append(records, record)
+	}
+
+	switch format {
+	case OutputFormatJSON:
+		out, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	case OutputFormatYAML:
+		out, err := yaml.Marshal(records)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	case OutputFormatCSV:
+		return formatCSV(records, columns)
+	case OutputFormatMarkdown:
+		return formatMarkdownTable(records, columns), nil
+	default:
+		return "", fmt.Errorf("invalid format (%s), available: %v", format, outputFormats)
+	}
+}
+
+func executeStepsTemplate(steps []Step, tmpl string) (string, error) {
+	t := template.New("steps")
+	t, err := t.Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var buff bytes.Buffer
+	if err := t.Execute(&buff, steps); err != nil {
+		return "", err
+	}
+
+	return buff.String(), nil
+}
+
+func formatCSV(records []StepRecord, columns []string) (string, error) {
+	var rows [][]string
+	for _, record := range records {
+		rows = append(rows, recordRow(record, columns))
+	}
+	return formatCSVRows(columns, rows)
+}
+
+func formatMarkdownTable(records []StepRecord, columns []string) string {
+	var rows [][]string
+	for _, record := range records {
+		rows = append(rows, recordRow(record, columns))
+	}
+	return formatMarkdownRows(columns, rows)
+}
+
+func formatCSVRows(header []string, rows [][]string) (string, error) {
+	var buff bytes.Buffer
+	writer := csv.NewWriter(&buff)
+
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buff.String(), "\n"), nil
+}
+
+func formatMarkdownRows(header []string, rows [][]string) string {
+	var lines []string
+
+	lines = append(lines, markdownTableRow(header))
+
+	var separators []string
+	for range header {
+		separators = append(separators, "---")
+	}
+	lines = append(lines, markdownTableRow(separators))
+
+	for _, row := range rows {
+		lines = append(lines, markdownTableRow(row))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func markdownTableRow(cells []string) string {
+	escaped := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		cell = strings.ReplaceAll(cell, "\n", " ")
+		escaped = append(escaped, cell)
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
+
+func recordRow(record StepRecord, columns []string) []string {
+	row := make([]string, 0, len(columns))
+	for _, column := range columns {
+		row = append(row, stepColumns[column](record))
+	}
+	return row
+}
+
+func envKeys(envs []envmanModels.EnvironmentItemModel) ([]string, error) {
+	var keys []string
+	for _, env := range envs {
+		key, _, err := env.GetKeyValuePair()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
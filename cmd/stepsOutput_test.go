@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	"github.com/bitrise-io/stepman/models"
+)
+
+func TestFormatSteps(t *testing.T) {
+	steps := []Step{
+		{
+			StepModel: models.StepModel{
+				Title:  pointers.NewStringPtr(`Git Clone, "shallow" | deep`),
+				Source: &models.StepSourceModel{Git: "https://github.com/bitrise-steplib/steps-git-clone.git"},
+				Inputs: []envmanModels.EnvironmentItemModel{
+					{"repository_url": ""},
+					{"clone_depth": ""},
+				},
+			},
+			StepID:        "git-clone",
+			Version:       "8.1.0",
+			AllVersions:   []string{"8.1.0", "8.0.0"},
+			LatestVersion: true,
+			Repository:    StepRepository{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-git-clone"},
+		},
+		{
+			StepModel: models.StepModel{
+				Title: pointers.NewStringPtr("Script\nrunner"),
+			},
+			StepID:      "script",
+			Version:     "1.1.5",
+			AllVersions: []string{"1.1.5"},
+			Repository:  StepRepository{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-script"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  OutputFormat
+		columns []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "csv default columns",
+			format: OutputFormatCSV,
+			want: "id,version,owner,repo\n" +
+				"git-clone,8.1.0,bitrise-steplib,steps-git-clone\n" +
+				"script,1.1.5,bitrise-steplib,steps-script",
+		},
+		{
+			name:    "csv selected columns with quoting",
+			format:  OutputFormatCSV,
+			columns: []string{"id", "title", "all_versions", "inputs"},
+			want: "id,title,all_versions,inputs\n" +
+				`git-clone,"Git Clone, ""shallow"" | deep",8.1.0 8.0.0,repository_url clone_depth` + "\n" +
+				"script,\"Script\nrunner\",1.1.5,",
+		},
+		{
+			name:    "markdown escaping",
+			format:  OutputFormatMarkdown,
+			columns: []string{"id", "title", "latest_version", "source"},
+			want: "| id | title | latest_version | source |\n" +
+				"| --- | --- | --- | --- |\n" +
+				`| git-clone | Git Clone, "shallow" \| deep | true | https://github.com/bitrise-steplib/steps-git-clone.git |` + "\n" +
+				"| script | Script runner | false |  |",
+		},
+		{
+			name:   "template",
+			format: OutputFormatTemplate,
+			want:   "0,git-clone\n1,script\n",
+		},
+		{
+			name:    "unknown column",
+			format:  OutputFormatCSV,
+			columns: []string{"id", "name"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatSteps(steps, tt.format, defaultPrintTemplate, tt.columns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatSteps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("formatSteps() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, format := range []string{"template", "json", "yaml", "csv", "markdown"} {
+		if got, err := parseOutputFormat(format); err != nil || string(got) != format {
+			t.Errorf("parseOutputFormat(%s) = (%s, %v)", format, got, err)
+		}
+	}
+	if _, err := parseOutputFormat("xml"); err == nil {
+		t.Errorf("parseOutputFormat(xml) expected an error")
+	}
+}
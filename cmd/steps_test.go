@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/stepman/models"
+)
+
+func TestSelectVersions(t *testing.T) {
+	allVersions := []string{"8.1.0", "8.0.0", "7.2.1", "7.2.0", "6.0.0"}
+
+	tests := []struct {
+		mode VersionsMode
+		want []string
+	}{
+		{mode: VersionsModeLatest, want: []string{"8.1.0"}},
+		{mode: VersionsModeAll, want: allVersions},
+		{mode: VersionsModeMajorLatest, want: []string{"8.1.0", "7.2.1", "6.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got, err := selectVersions(allVersions, "8.1.0", tt.mode)
+			if err != nil {
+				t.Fatalf("selectVersions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortSteps(t *testing.T) {
+	published := func(day int) *time.Time {
+		publishedAt := time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+		return &publishedAt
+	}
+	newStep := func(stepID, version string, publishedAt *time.Time, repo string) Step {
+		return Step{
+			StepModel:  models.StepModel{PublishedAt: publishedAt},
+			StepID:     stepID,
+			Version:    version,
+			Repository: StepRepository{Host: "github.com", Owner: "bitrise-steplib", Repo: repo},
+		}
+	}
+	steps := []Step{
+		newStep("script", "1.1.5", published(5), "steps-script"),
+		newStep("git-clone", "8.0.0", published(1), "steps-git-clone"),
+		newStep("git-clone", "8.10.0", published(10), "steps-git-clone"),
+		newStep("git-clone", "8.2.0", published(10), "steps-git-clone"),
+		newStep("activate-ssh-key", "4.1.0", nil, "steps-activate-ssh-key"),
+		newStep("git-clone-lfs", "1.0.0", published(3), "steps-git-clone"),
+	}
+
+	tests := []struct {
+		sortBy SortKey
+		want   []string
+	}{
+		{sortBy: SortKeyID, want: []string{"activate-ssh-key@4.1.0", "git-clone@8.10.0", "git-clone@8.2.0", "git-clone@8.0.0", "git-clone-lfs@1.0.0", "script@1.1.5"}},
+		{sortBy: SortKeyPublished, want: []string{"git-clone@8.10.0", "git-clone@8.2.0", "script@1.1.5", "git-clone-lfs@1.0.0", "git-clone@8.0.0", "activate-ssh-key@4.1.0"}},
+		{sortBy: SortKeyRepo, want: []string{"activate-ssh-key@4.1.0", "git-clone@8.10.0", "git-clone@8.2.0", "git-clone@8.0.0", "git-clone-lfs@1.0.0", "script@1.1.5"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.sortBy), func(t *testing.T) {
+			sorted := append([]Step(nil), steps...)
+			if err := sortSteps(sorted, tt.sortBy); err != nil {
+				t.Fatalf("sortSteps() error = %v", err)
+			}
+			if got := stepReferences(sorted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortSteps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupStepsByRepo(t *testing.T) {
+	steps := []Step{
+		{StepID: "git-clone", Version: "8.1.0", Repository: StepRepository{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-git-clone"}},
+		{StepID: "script", Version: "1.1.5", Repository: StepRepository{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-script"}},
+		{StepID: "git-clone-lfs", Version: "1.0.0", Repository: StepRepository{Host: "github.com", Owner: "Bitrise-Steplib", Repo: "Steps-Git-Clone"}},
+		{StepID: "git-clone", Version: "8.0.0", Repository: StepRepository{Host: "github.com", Owner: "bitrise-steplib", Repo: "steps-git-clone"}},
+	}
+
+	want := []string{"git-clone@8.1.0", "script@1.1.5"}
+	if got := stepReferences(groupStepsByRepo(steps)); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupStepsByRepo() = %v, want %v", got, want)
+	}
+}
+
+func stepReferences(steps []Step) []string {
+	var references []string
+	for _, step := range steps {
+		references = append(references, step.StepID+"@"+step.Version)
+	}
+	return references
+}
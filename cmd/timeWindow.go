@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTimeFlag parses a time given in 2006-01-02 or RFC3339 format.
+func parseTimeFlag(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(lastReleaseTimeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time (%s), expected format: %s or RFC3339", value, lastReleaseTimeLayout)
+	}
+	return t, nil
+}
+
+// parseEndTimeFlag parses the end of a time window given in 2006-01-02 or RFC3339 format,
+// a date without time means the end of the day, so the window includes the whole end day.
+func parseEndTimeFlag(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := parseTimeFlag(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// parseRelativeDuration parses durations like 30m, 12h, 3d or 2w.
+func parseRelativeDuration(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid duration (%s), expected format: <number><m|h|d|w>, e.g. 2w", value)
+	}
+
+	unit, ok := units[value[len(value)-1:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid duration (%s), expected format: <number><m|h|d|w>, e.g. 2w", value)
+	}
+
+	amount, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("invalid duration (%s), expected format: <number><m|h|d|w>, e.g. 2w", value)
+	}
+
+	return time.Duration(amount) * unit, nil
+}
+
+// lastRunState is persisted by the commands supporting the '--since-last-run' mode.
+type lastRunState struct {
+	// LastRunEnd is the end of the time window of the last successful run.
+	LastRunEnd time.Time `json:"last_run_end"`
+}
+
+func defaultStateFilePath(name string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "stepper", name+"-state.json"), nil
+}
+
+func readLastRunState(pth string) (lastRunState, bool, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return lastRunState{}, false, nil
+		}
+		return lastRunState{}, false, err
+	}
+
+	var state lastRunState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return lastRunState{}, false, fmt.Errorf("invalid state file (%s): %w", pth, err)
+	}
+	return state, true, nil
+}
+
+func writeLastRunState(pth string, state lastRunState) error {
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pth, content, 0644)
+}
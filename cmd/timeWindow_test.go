@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseEndTimeFlag(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2024-03-10", want: time.Date(2024, 3, 10, 23, 59, 59, 999999999, time.UTC)},
+		{value: "2024-03-10T12:00:00Z", want: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{value: "10/03/2024", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseEndTimeFlag(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEndTimeFlag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseEndTimeFlag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRelativeDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30m", want: 30 * time.Minute},
+		{value: "12h", want: 12 * time.Hour},
+		{value: "3d", want: 3 * 24 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "d", wantErr: true},
+		{value: "0d", wantErr: true},
+		{value: "2y", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseRelativeDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRelativeDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRelativeDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastRunState(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "state", "stepChanges-state.json")
+
+	if _, found, err := readLastRunState(pth); err != nil || found {
+		t.Fatalf("readLastRunState() = (%v, %v), want not found", found, err)
+	}
+
+	state := lastRunState{LastRunEnd: time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC)}
+	if err := writeLastRunState(pth, state); err != nil {
+		t.Fatalf("writeLastRunState() error = %v", err)
+	}
+
+	got, found, err := readLastRunState(pth)
+	if err != nil || !found {
+		t.Fatalf("readLastRunState() = (%v, %v), want found", found, err)
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Errorf("readLastRunState() = %+v, want %+v", got, state)
+	}
+}
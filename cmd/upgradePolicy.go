@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/godrei/stepper/tools"
+	ver "github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v2"
+)
+
+// UpgradePolicy restricts the step version upgrades.
+//
+//	max_bump: minor
+//	steps:
+//	  git-clone:
+//	    max_bump: major
+//	  xcode-archive:
+//	    hold: true
+//	    reason: waiting for the Xcode 15 stack
+type UpgradePolicy struct {
+	// MaxBump is the largest allowed version bump of the steps: major (default, the latest version), minor or patch.
+	MaxBump tools.BumpType               `yaml:"max_bump"`
+	Steps   map[string]StepUpgradePolicy `yaml:"steps"`
+}
+
+// StepUpgradePolicy overrides the policy of a step.
+type StepUpgradePolicy struct {
+	MaxBump tools.BumpType `yaml:"max_bump"`
+	// Hold keeps the current version of the step.
+	Hold   bool   `yaml:"hold"`
+	Reason string `yaml:"reason"`
+}
+
+// ReadUpgradePolicy ...
+func ReadUpgradePolicy(pth string) (UpgradePolicy, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return UpgradePolicy{}, err
+	}
+
+	var policy UpgradePolicy
+	if err := yaml.UnmarshalStrict(content, &policy); err != nil {
+		return UpgradePolicy{}, fmt.Errorf("invalid upgrade policy (%s): %w", pth, err)
+	}
+
+	if err := validateMaxBump(policy.MaxBump); err != nil {
+		return UpgradePolicy{}, err
+	}
+	for stepID, stepPolicy := range policy.Steps {
+		if err := validateMaxBump(stepPolicy.MaxBump); err != nil {
+			return UpgradePolicy{}, fmt.Errorf("%s: %w", stepID, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// upgradePolicyFromFlags reads the policy file, if defined, and applies the max bump and the held steps of the flags on it.
+func upgradePolicyFromFlags(policyPth, maxBump string, holdStepIDs []string) (UpgradePolicy, error) {
+	var policy UpgradePolicy
+	if policyPth != "" {
+		var err error
+		policy, err = ReadUpgradePolicy(policyPth)
+		if err != nil {
+			return UpgradePolicy{}, err
+		}
+	}
+
+	if maxBump != "" {
+		if err := validateMaxBump(tools.BumpType(maxBump)); err != nil {
+			return UpgradePolicy{}, err
+		}
+		policy.MaxBump = tools.BumpType(maxBump)
+	}
+
+	for _, stepID := range holdStepIDs {
+		if policy.Steps == nil {
+			policy.Steps = map[string]StepUpgradePolicy{}
+		}
+		stepPolicy := policy.Steps[stepID]
+		stepPolicy.Hold = true
+		policy.Steps[stepID] = stepPolicy
+	}
+
+	return policy, nil
+}
+
+func validateMaxBump(bump tools.BumpType) error {
+	switch bump {
+	case "", tools.BumpTypeMajor, tools.BumpTypeMinor, tools.BumpTypePatch:
+		return nil
+	}
+	return fmt.Errorf("invalid max bump (%s), available: [major, minor, patch]", bump)
+}
+
+// StepPolicy returns the policy of the step, with the step's max bump defaulting to the policy's max bump.
+func (p UpgradePolicy) StepPolicy(stepID string) StepUpgradePolicy {
+	stepPolicy := p.Steps[stepID]
+	if stepPolicy.MaxBump == "" {
+		stepPolicy.MaxBump = p.MaxBump
+	}
+	if stepPolicy.MaxBump == "" {
+		stepPolicy.MaxBump = tools.BumpTypeMajor
+	}
+	return stepPolicy
+}
+
+// Description tells why the policy holds back a version, like 'held: waiting for the Xcode 15 stack' or 'max bump: minor'.
+func (p StepUpgradePolicy) Description() string {
+	if p.Hold {
+		if p.Reason != "" {
+			return "held: " + p.Reason
+		}
+		return "held"
+	}
+
+	description := fmt.Sprintf("max bump: %s", p.MaxBump)
+	if p.Reason != "" {
+		description += ", " + p.Reason
+	}
+	return description
+}
+
+// allowedVersion returns the highest released (not prerelease) version, which the policy allows to upgrade to from the current version.
+// The current version is never downgraded.
+func (p StepUpgradePolicy) allowedVersion(current string, versions []string) (string, error) {
+	if p.Hold {
+		return current, nil
+	}
+
+	currentVersion, err := ver.NewVersion(current)
+	if err != nil {
+		return "", fmt.Errorf("invalid current version (%s): %w", current, err)
+	}
+
+	sorted, err := tools.SortVersionsDesc(versions)
+	if err != nil {
+		return "", err
+	}
+
+	for _, version := range sorted {
+		v, err := ver.NewVersion(version)
+		if err != nil {
+			return "", err
+		}
+		if !currentVersion.LessThan(v) {
+			break
+		}
+		if v.Prerelease() != "" {
+			continue
+		}
+
+		bump, err := tools.VersionBump(current, version)
+		if err != nil {
+			return "", err
+		}
+		if bumpRank(bump) <= bumpRank(p.MaxBump) {
+			return version, nil
+		}
+	}
+
+	return current, nil
+}
+
+func bumpRank(bump tools.BumpType) int {
+	switch bump {
+	case tools.BumpTypeMajor:
+		return 2
+	case tools.BumpTypeMinor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// heldBackStep is a step, whose latest version is not allowed by the upgrade policy.
+type heldBackStep struct {
+	StepID        string
+	Version       string
+	LatestVersion string
+	Policy        StepUpgradePolicy
+}
+
+// newHeldBackStep returns the held back step, if the version allowed by the policy is lower than
+// the version the current version would be upgraded to without restrictions.
+// A prerelease latest version or a current version above the latest is not held back.
+func newHeldBackStep(stepID string, policy StepUpgradePolicy, current, allowed string, versions []string) (heldBackStep, bool, error) {
+	latestVersion, err := StepUpgradePolicy{MaxBump: tools.BumpTypeMajor}.allowedVersion(current, versions)
+	if err != nil {
+		return heldBackStep{}, false, err
+	}
+	if allowed == latestVersion {
+		return heldBackStep{}, false, nil
+	}
+	return heldBackStep{StepID: stepID, Version: allowed, LatestVersion: latestVersion, Policy: policy}, true, nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/godrei/stepper/tools"
+)
+
+func TestStepUpgradePolicy_AllowedVersion(t *testing.T) {
+	versions := []string{"7.0.0", "8.0.0", "8.0.1", "8.1.0", "8.2.0-beta.1", "9.0.0", "10.0.0-rc.1"}
+
+	tests := []struct {
+		name    string
+		policy  StepUpgradePolicy
+		current string
+		want    string
+		wantErr bool
+	}{
+		{name: "major", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor}, current: "8.0.0", want: "9.0.0"},
+		{name: "minor", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMinor}, current: "8.0.0", want: "8.1.0"},
+		{name: "patch", policy: StepUpgradePolicy{MaxBump: tools.BumpTypePatch}, current: "8.0.0", want: "8.0.1"},
+		{name: "no allowed upgrade", policy: StepUpgradePolicy{MaxBump: tools.BumpTypePatch}, current: "8.1.0", want: "8.1.0"},
+		{name: "hold", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor, Hold: true}, current: "7.0.0", want: "7.0.0"},
+		{name: "latest", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor}, current: "9.0.0", want: "9.0.0"},
+		{name: "no downgrade", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor}, current: "11.0.0", want: "11.0.0"},
+		{name: "invalid current version", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor}, current: "8.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.allowedVersion(tt.current, versions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("allowedVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("allowedVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHeldBackStep(t *testing.T) {
+	versions := []string{"7.0.0", "8.0.0", "8.1.0", "9.0.0", "10.0.0-rc.1"}
+
+	tests := []struct {
+		name     string
+		policy   StepUpgradePolicy
+		current  string
+		want     heldBackStep
+		wantHeld bool
+	}{
+		{name: "max bump holds back the major version", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMinor}, current: "8.0.0", want: heldBackStep{StepID: "git-clone", Version: "8.1.0", LatestVersion: "9.0.0", Policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMinor}}, wantHeld: true},
+		{name: "hold", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor, Hold: true}, current: "7.0.0", want: heldBackStep{StepID: "git-clone", Version: "7.0.0", LatestVersion: "9.0.0", Policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor, Hold: true}}, wantHeld: true},
+		{name: "max bump allows the latest version", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMinor}, current: "9.0.0"},
+		{name: "prerelease latest version", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor}, current: "8.0.0"},
+		{name: "current version above the latest", policy: StepUpgradePolicy{MaxBump: tools.BumpTypePatch}, current: "11.0.0"},
+		{name: "hold at the latest version", policy: StepUpgradePolicy{MaxBump: tools.BumpTypeMajor, Hold: true}, current: "9.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := tt.policy.allowedVersion(tt.current, versions)
+			if err != nil {
+				t.Fatalf("allowedVersion() error = %v", err)
+			}
+			got, held, err := newHeldBackStep("git-clone", tt.policy, tt.current, allowed, versions)
+			if err != nil {
+				t.Fatalf("newHeldBackStep() error = %v", err)
+			}
+			if held != tt.wantHeld || got != tt.want {
+				t.Errorf("newHeldBackStep() = (%+v, %v), want (%+v, %v)", got, held, tt.want, tt.wantHeld)
+			}
+		})
+	}
+}
+
+func TestUpgradePolicyFromFlags(t *testing.T) {
+	policyPth := filepath.Join(t.TempDir(), "policy.yml")
+	content := "max_bump: minor\nsteps:\n  git-clone:\n    max_bump: major\n  xcode-archive:\n    hold: true\n    reason: waiting for the Xcode 15 stack\n"
+	if err := os.WriteFile(policyPth, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		policyPth   string
+		maxBump     string
+		holdStepIDs []string
+		want        map[string]StepUpgradePolicy
+		wantErr     bool
+	}{
+		{
+			name: "defaults",
+			want: map[string]StepUpgradePolicy{
+				"git-clone": {MaxBump: tools.BumpTypeMajor},
+			},
+		},
+		{
+			name:      "policy file",
+			policyPth: policyPth,
+			want: map[string]StepUpgradePolicy{
+				"git-clone":     {MaxBump: tools.BumpTypeMajor},
+				"xcode-archive": {MaxBump: tools.BumpTypeMinor, Hold: true, Reason: "waiting for the Xcode 15 stack"},
+				"script":        {MaxBump: tools.BumpTypeMinor},
+			},
+		},
+		{
+			name:        "flags override the policy file",
+			policyPth:   policyPth,
+			maxBump:     "patch",
+			holdStepIDs: []string{"git-clone"},
+			want: map[string]StepUpgradePolicy{
+				"git-clone": {MaxBump: tools.BumpTypeMajor, Hold: true},
+				"script":    {MaxBump: tools.BumpTypePatch},
+			},
+		},
+		{
+			name:    "invalid max bump",
+			maxBump: "build",
+			wantErr: true,
+		},
+		{
+			name:      "missing policy file",
+			policyPth: filepath.Join(t.TempDir(), "missing.yml"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := upgradePolicyFromFlags(tt.policyPth, tt.maxBump, tt.holdStepIDs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("upgradePolicyFromFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for stepID, want := range tt.want {
+				if got := policy.StepPolicy(stepID); !reflect.DeepEqual(got, want) {
+					t.Errorf("StepPolicy(%s) = %+v, want %+v", stepID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadUpgradePolicy_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid max bump", content: "max_bump: build\n"},
+		{name: "invalid step max bump", content: "steps:\n  git-clone:\n    max_bump: any\n"},
+		{name: "unknown field", content: "hold: [git-clone]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "policy.yml")
+			if err := os.WriteFile(pth, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadUpgradePolicy(pth); err == nil {
+				t.Errorf("ReadUpgradePolicy() expected an error")
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ module github.com/godrei/stepper
 go 1.20
 
 require (
+	github.com/bitrise-io/envman v0.0.0-20230802102824-1300c57d49c4
 	github.com/bitrise-io/go-utils v1.0.9
 	github.com/bitrise-io/go-utils/v2 v2.0.0-alpha.19
 	github.com/bitrise-io/stepman v0.0.0-20230728094915-939f0fe5c19a
@@ -11,12 +12,14 @@ require (
 	github.com/kubescape/go-git-url v0.0.25
 	github.com/spf13/cobra v1.7.0
 	golang.org/x/oauth2 v0.11.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
-	github.com/bitrise-io/envman v0.0.0-20230802102824-1300c57d49c4 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
+	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
+	github.com/hashicorp/go-retryablehttp v0.7.4 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/whilp/git-urls v1.0.0 // indirect
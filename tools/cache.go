@@ -0,0 +1,300 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/stepman/models"
+)
+
+// RevisionedSteplibSource is a SteplibSource, which can tell the revision (commit hash) of the StepLib.
+type RevisionedSteplibSource interface {
+	SteplibSource
+	// Revision returns the current revision of the StepLib, an empty revision means the StepLib is not versioned
+	// or has local changes, so its spec can not be cached.
+	Revision() (string, error)
+}
+
+// RemoteSteplibSource is a RevisionedSteplibSource, which reads the spec from a local copy of a remote StepLib.
+type RemoteSteplibSource interface {
+	RevisionedSteplibSource
+	// Update updates the local copy to the latest revision of the remote StepLib.
+	Update() error
+	// LocalSpec returns the spec of the local copy without updating it.
+	LocalSpec(exportType ExportTypes) (models.StepCollectionModel, error)
+}
+
+// CacheOptions ...
+type CacheOptions struct {
+	// Dir is the root dir of the cache.
+	Dir string
+	// MaxAge is the age until a cached spec is used without checking the StepLib revision.
+	MaxAge time.Duration
+	// Offline uses the last cached spec without updating the StepLib,
+	// the local StepLibs without a cached spec are read directly.
+	Offline bool
+	// Refresh ignores the cached specs and exports the spec again.
+	Refresh bool
+}
+
+// CachedSteplibSource caches the exported StepLib specs on the disk,
+// keyed by the StepLib URI, the export type and the StepLib revision.
+type CachedSteplibSource struct {
+	source SteplibSource
+	opts   CacheOptions
+}
+
+type cacheEntry struct {
+	URI        string      `json:"uri"`
+	ExportType ExportTypes `json:"export_type"`
+	Revision   string      `json:"revision"`
+	ExportedAt time.Time   `json:"exported_at"`
+}
+
+// DefaultCacheDir returns the default dir of the StepLib spec cache.
+func DefaultCacheDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, "stepper", "steplib-specs"), nil
+}
+
+// NewCachedSteplibSource ...
+func NewCachedSteplibSource(source SteplibSource, opts CacheOptions) CachedSteplibSource {
+	return CachedSteplibSource{source: source, opts: opts}
+}
+
+// URI ...
+func (s CachedSteplibSource) URI() string {
+	return s.source.URI()
+}
+
+// Spec ...
+func (s CachedSteplibSource) Spec(exportType ExportTypes) (models.StepCollectionModel, error) {
+	revisionedSource, ok := s.source.(RevisionedSteplibSource)
+	if !ok {
+		// Nothing to update or cache, like a spec.json file.
+		return s.source.Spec(exportType)
+	}
+
+	lastEntry, lastEntryFound, err := s.readLastEntry(exportType)
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	remoteSource, isRemote := s.source.(RemoteSteplibSource)
+
+	if s.opts.Offline {
+		if lastEntryFound {
+			return s.readSpec(lastEntry)
+		}
+		if isRemote {
+			return models.StepCollectionModel{}, fmt.Errorf("no cached %s spec found for steplib (%s)", exportType, s.source.URI())
+		}
+	} else if lastEntryFound && !s.opts.Refresh && time.Since(lastEntry.ExportedAt) < s.opts.MaxAge {
+		return s.readSpec(lastEntry)
+	}
+
+	// The spec is exported from the same local copy, whose revision is the cache key.
+	exportSpec := s.source.Spec
+	if isRemote {
+		if !s.opts.Offline {
+			if err := remoteSource.Update(); err != nil {
+				return models.StepCollectionModel{}, err
+			}
+		}
+		exportSpec = remoteSource.LocalSpec
+	}
+
+	revision, err := revisionedSource.Revision()
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+	if revision == "" {
+		return exportSpec(exportType)
+	}
+
+	entry := cacheEntry{
+		URI:        s.source.URI(),
+		ExportType: exportType,
+		Revision:   revision,
+		ExportedAt: time.Now(),
+	}
+
+	if !s.opts.Refresh {
+		if exist, err := pathutil.IsPathExists(s.specPath(entry)); err != nil {
+			return models.StepCollectionModel{}, err
+		} else if exist {
+			// The StepLib did not change since the last export, only the entry's age is reset.
+			if err := s.writeLastEntry(entry); err != nil {
+				return models.StepCollectionModel{}, err
+			}
+			return s.readSpec(entry)
+		}
+	}
+
+	spec, err := exportSpec(exportType)
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	if err := s.writeSpec(entry, spec); err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	return spec, nil
+}
+
+func (s CachedSteplibSource) entryDir() string {
+	hash := sha256.Sum256([]byte(s.source.URI()))
+	return filepath.Join(s.opts.Dir, hex.EncodeToString(hash[:])[:16])
+}
+
+func (s CachedSteplibSource) lastEntryPath(exportType ExportTypes) string {
+	return filepath.Join(s.entryDir(), string(exportType)+".last.json")
+}
+
+func (s CachedSteplibSource) specPath(entry cacheEntry) string {
+	return filepath.Join(s.entryDir(), fmt.Sprintf("%s-%s.json", entry.ExportType, entry.Revision))
+}
+
+func (s CachedSteplibSource) readLastEntry(exportType ExportTypes) (cacheEntry, bool, error) {
+	content, err := os.ReadFile(s.lastEntryPath(exportType))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cacheEntry{}, false, nil
+		}
+		return cacheEntry{}, false, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return cacheEntry{}, false, fmt.Errorf("invalid cache entry (%s): %w", s.lastEntryPath(exportType), err)
+	}
+
+	if exist, err := pathutil.IsPathExists(s.specPath(entry)); err != nil {
+		return cacheEntry{}, false, err
+	} else if !exist {
+		return cacheEntry{}, false, nil
+	}
+
+	return entry, true, nil
+}
+
+func (s CachedSteplibSource) writeLastEntry(entry cacheEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomically(s.lastEntryPath(entry.ExportType), content)
+}
+
+func (s CachedSteplibSource) readSpec(entry cacheEntry) (models.StepCollectionModel, error) {
+	content, err := fileutil.ReadBytesFromFile(s.specPath(entry))
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	var spec models.StepCollectionModel
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	return spec, nil
+}
+
+func (s CachedSteplibSource) writeSpec(entry cacheEntry, spec models.StepCollectionModel) error {
+	if err := s.writeSpecFile(entry, spec); err != nil {
+		return err
+	}
+
+	return s.writeLastEntry(entry)
+}
+
+func (s CachedSteplibSource) writeSpecFile(entry cacheEntry, spec models.StepCollectionModel) error {
+	if err := os.MkdirAll(s.entryDir(), 0755); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomically(s.specPath(entry), content)
+}
+
+// WriteFileAtomically writes the content to a temporary file next to the given path and renames it to the path,
+// so readers never see a partially written file. An existing file keeps its permissions.
+func WriteFileAtomically(pth string, content []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(pth), filepath.Base(pth)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	// Keep the permissions of the replaced file.
+	if info, err := os.Stat(pth); err == nil {
+		if err := tmpFile.Chmod(info.Mode().Perm()); err != nil {
+			_ = tmpFile.Close()
+			_ = os.Remove(tmpFile.Name())
+			return err
+		}
+	}
+
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), pth)
+}
+
+// gitRevision returns the HEAD commit hash of the git repository,
+// empty if the dir is not a git repository or its worktree has local changes.
+func gitRevision(dir string) (string, error) {
+	revision, err := gitHeadRevision(dir)
+	if err != nil || revision == "" {
+		return "", err
+	}
+
+	out, err := command.New("git", "status", "--porcelain").SetDir(dir).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the status of %s: %s: %w", dir, out, err)
+	}
+	if out != "" {
+		// The spec of a dirty worktree does not belong to the HEAD revision.
+		return "", nil
+	}
+
+	return revision, nil
+}
+
+// gitHeadRevision returns the HEAD commit hash of the git repository, empty if the dir is not a git repository.
+func gitHeadRevision(dir string) (string, error) {
+	if exist, err := pathutil.IsDirExists(filepath.Join(dir, ".git")); err != nil {
+		return "", err
+	} else if !exist {
+		return "", nil
+	}
+
+	out, err := command.New("git", "rev-parse", "HEAD").SetDir(dir).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the revision of %s: %s: %w", dir, out, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,295 @@
+package tools
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/stepman/models"
+)
+
+// fakeRemoteSource is a RemoteSteplibSource, which counts the updates and the exports.
+type fakeRemoteSource struct {
+	revision string
+	updates  int
+	exports  int
+}
+
+func (s *fakeRemoteSource) URI() string {
+	return "https://github.com/bitrise-io/bitrise-steplib.git"
+}
+
+func (s *fakeRemoteSource) Spec(exportType ExportTypes) (models.StepCollectionModel, error) {
+	if err := s.Update(); err != nil {
+		return models.StepCollectionModel{}, err
+	}
+	return s.LocalSpec(exportType)
+}
+
+func (s *fakeRemoteSource) Update() error {
+	s.updates++
+	return nil
+}
+
+func (s *fakeRemoteSource) LocalSpec(exportType ExportTypes) (models.StepCollectionModel, error) {
+	s.exports++
+	// The revision is exported as the steplib source to tell the cached specs apart.
+	return models.StepCollectionModel{SteplibSource: s.revision, Steps: models.StepHash{}}, nil
+}
+
+func (s *fakeRemoteSource) Revision() (string, error) {
+	return s.revision, nil
+}
+
+func TestCachedSteplibSource_Spec(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CacheOptions
+		// lastEntry is the revision of the last cached spec and the time since its export, no cached spec if empty.
+		lastEntry    string
+		lastEntryAge time.Duration
+		revision     string
+		wantSpec     string
+		wantUpdates  int
+		wantExports  int
+		wantErr      bool
+	}{
+		{
+			name:        "cache miss",
+			revision:    "rev2",
+			wantSpec:    "rev2",
+			wantUpdates: 1,
+			wantExports: 1,
+		},
+		{
+			name:         "cache hit",
+			lastEntry:    "rev1",
+			lastEntryAge: time.Hour,
+			revision:     "rev1",
+			wantSpec:     "cached rev1",
+			wantUpdates:  1,
+		},
+		{
+			name:         "changed revision",
+			lastEntry:    "rev1",
+			lastEntryAge: time.Hour,
+			revision:     "rev2",
+			wantSpec:     "rev2",
+			wantUpdates:  1,
+			wantExports:  1,
+		},
+		{
+			name:         "within max age",
+			opts:         CacheOptions{MaxAge: 2 * time.Hour},
+			lastEntry:    "rev1",
+			lastEntryAge: time.Hour,
+			revision:     "rev2",
+			wantSpec:     "cached rev1",
+		},
+		{
+			name:         "max age expired",
+			opts:         CacheOptions{MaxAge: 30 * time.Minute},
+			lastEntry:    "rev1",
+			lastEntryAge: time.Hour,
+			revision:     "rev2",
+			wantSpec:     "rev2",
+			wantUpdates:  1,
+			wantExports:  1,
+		},
+		{
+			name:         "refresh",
+			opts:         CacheOptions{Refresh: true, MaxAge: 2 * time.Hour},
+			lastEntry:    "rev1",
+			lastEntryAge: time.Hour,
+			revision:     "rev1",
+			wantSpec:     "rev1",
+			wantUpdates:  1,
+			wantExports:  1,
+		},
+		{
+			name:         "offline",
+			opts:         CacheOptions{Offline: true},
+			lastEntry:    "rev1",
+			lastEntryAge: 24 * time.Hour,
+			revision:     "rev2",
+			wantSpec:     "cached rev1",
+		},
+		{
+			name:     "offline without cached spec",
+			opts:     CacheOptions{Offline: true},
+			revision: "rev1",
+			wantErr:  true,
+		},
+		{
+			name:        "not versioned",
+			revision:    "",
+			wantSpec:    "",
+			wantUpdates: 1,
+			wantExports: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeRemoteSource{revision: tt.revision}
+			opts := tt.opts
+			opts.Dir = t.TempDir()
+			cachedSource := NewCachedSteplibSource(source, opts)
+
+			if tt.lastEntry != "" {
+				entry := cacheEntry{URI: source.URI(), ExportType: ExportTypesFull, Revision: tt.lastEntry, ExportedAt: time.Now().Add(-tt.lastEntryAge)}
+				if err := cachedSource.writeSpec(entry, models.StepCollectionModel{SteplibSource: "cached " + tt.lastEntry}); err != nil {
+					t.Fatalf("writeSpec() error = %v", err)
+				}
+			}
+
+			spec, err := cachedSource.Spec(ExportTypesFull)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Spec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if spec.SteplibSource != tt.wantSpec {
+				t.Errorf("Spec() = %s, want %s", spec.SteplibSource, tt.wantSpec)
+			}
+			if source.updates != tt.wantUpdates {
+				t.Errorf("Spec() updated the StepLib %d times, want %d", source.updates, tt.wantUpdates)
+			}
+			if source.exports != tt.wantExports {
+				t.Errorf("Spec() exported the spec %d times, want %d", source.exports, tt.wantExports)
+			}
+		})
+	}
+}
+
+func TestCachedSteplibSource_Spec_CachesExport(t *testing.T) {
+	source := &fakeRemoteSource{revision: "rev1"}
+	cachedSource := NewCachedSteplibSource(source, CacheOptions{Dir: t.TempDir()})
+
+	for i := 0; i < 2; i++ {
+		if _, err := cachedSource.Spec(ExportTypesFull); err != nil {
+			t.Fatalf("Spec() error = %v", err)
+		}
+	}
+
+	if source.exports != 1 {
+		t.Errorf("Spec() exported the spec %d times, want 1", source.exports)
+	}
+}
+
+func TestCachedSteplibSource_Spec_LocalSources(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     func(t *testing.T) SteplibSource
+		opts       CacheOptions
+		wantCached bool
+	}{
+		{
+			name:   "spec file offline",
+			source: func(t *testing.T) SteplibSource { return SpecFileSource{Path: "testdata/spec.json"} },
+			opts:   CacheOptions{Offline: true},
+		},
+		{
+			name:   "not versioned steplib dir offline",
+			source: func(t *testing.T) SteplibSource { return LocalSteplibSource{Dir: "testdata/steplib"} },
+			opts:   CacheOptions{Offline: true},
+		},
+		{
+			name:       "git steplib checkout",
+			source:     func(t *testing.T) SteplibSource { return LocalSteplibSource{Dir: gitSteplib(t, false)} },
+			wantCached: true,
+		},
+		{
+			name:       "git steplib checkout offline",
+			source:     func(t *testing.T) SteplibSource { return LocalSteplibSource{Dir: gitSteplib(t, false)} },
+			opts:       CacheOptions{Offline: true},
+			wantCached: true,
+		},
+		{
+			name:   "git steplib checkout with local changes",
+			source: func(t *testing.T) SteplibSource { return LocalSteplibSource{Dir: gitSteplib(t, true)} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Dir = t.TempDir()
+			cachedSource := NewCachedSteplibSource(tt.source(t), opts)
+
+			spec, err := cachedSource.Spec(ExportTypesFull)
+			if err != nil {
+				t.Fatalf("Spec() error = %v", err)
+			}
+			if _, ok := spec.Steps["git-clone"]; !ok {
+				t.Errorf("Spec() has no git-clone step")
+			}
+
+			_, cached, err := cachedSource.readLastEntry(ExportTypesFull)
+			if err != nil {
+				t.Fatalf("readLastEntry() error = %v", err)
+			}
+			if cached != tt.wantCached {
+				t.Errorf("Spec() cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+// gitSteplib copies the testdata StepLib into a new git repository, dirty leaves an uncommitted change in it.
+func gitSteplib(t *testing.T, dirty bool) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	dir := t.TempDir()
+	if err := copyDir("testdata/steplib", dir); err != nil {
+		t.Fatalf("failed to copy the StepLib: %v", err)
+	}
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s: %v", args, out, err)
+		}
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	if dirty {
+		if err := os.WriteFile(filepath.Join(dir, "steps", "script", "step-info.yml"), []byte("deprecate_notes: Use bash instead.\n"), 0644); err != nil {
+			t.Fatalf("failed to change the StepLib: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, pth)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := os.ReadFile(pth)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, info.Mode().Perm())
+	})
+}
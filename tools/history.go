@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/bitrise-io/stepman/stepman"
+)
+
+// ErrNoHistory means the StepLib source can not provide the spec of an earlier StepLib state.
+var ErrNoHistory = errors.New("steplib has no history")
+
+// HistoricalSteplibSource is a RevisionedSteplibSource, which can provide the spec of earlier StepLib revisions.
+type HistoricalSteplibSource interface {
+	RevisionedSteplibSource
+	// RevisionAt returns the StepLib revision at the given time, an empty revision means the StepLib did not exist yet.
+	RevisionAt(t time.Time) (string, error)
+	// SpecAtRevision returns the spec of the given StepLib revision.
+	SpecAtRevision(revision string, exportType ExportTypes) (models.StepCollectionModel, error)
+}
+
+// RevisionAt ...
+func (s LocalSteplibSource) RevisionAt(t time.Time) (string, error) {
+	return gitRevisionAt(s.Dir, t)
+}
+
+// SpecAtRevision ...
+func (s LocalSteplibSource) SpecAtRevision(revision string, exportType ExportTypes) (models.StepCollectionModel, error) {
+	return specAtRevision(s.Dir, revision, exportType)
+}
+
+// RevisionAt returns the revision of stepman's local StepLib clone at the given time.
+func (s StepmanSource) RevisionAt(t time.Time) (string, error) {
+	route, found := stepman.ReadRoute(s.CollectionURI)
+	if !found {
+		return "", fmt.Errorf("steplib (%s) is not set up", s.CollectionURI)
+	}
+	return gitRevisionAt(stepman.GetLibraryBaseDirPath(route), t)
+}
+
+// SpecAtRevision ...
+func (s StepmanSource) SpecAtRevision(revision string, exportType ExportTypes) (models.StepCollectionModel, error) {
+	route, found := stepman.ReadRoute(s.CollectionURI)
+	if !found {
+		return models.StepCollectionModel{}, fmt.Errorf("steplib (%s) is not set up", s.CollectionURI)
+	}
+	return specAtRevision(stepman.GetLibraryBaseDirPath(route), revision, exportType)
+}
+
+// SpecAt returns the spec of the StepLib at the given time, the spec is cached by the StepLib revision.
+func (s CachedSteplibSource) SpecAt(t time.Time, exportType ExportTypes) (models.StepCollectionModel, error) {
+	historicalSource, ok := s.source.(HistoricalSteplibSource)
+	if !ok {
+		return models.StepCollectionModel{}, fmt.Errorf("%w: %s", ErrNoHistory, s.source.URI())
+	}
+
+	revision, err := historicalSource.RevisionAt(t)
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+	if revision == "" {
+		return models.StepCollectionModel{}, fmt.Errorf("%w: %s has no revision before %s", ErrNoHistory, s.source.URI(), t.Format(time.RFC3339))
+	}
+
+	entry := cacheEntry{
+		URI:        s.source.URI(),
+		ExportType: exportType,
+		Revision:   revision,
+		ExportedAt: time.Now(),
+	}
+
+	if !s.opts.Refresh {
+		if exist, err := pathutil.IsPathExists(s.specPath(entry)); err != nil {
+			return models.StepCollectionModel{}, err
+		} else if exist {
+			return s.readSpec(entry)
+		}
+	}
+
+	spec, err := historicalSource.SpecAtRevision(revision, exportType)
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	if err := s.writeSpecFile(entry, spec); err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	return spec, nil
+}
+
+// gitRevisionAt returns the last commit of the repository's current branch committed before the given time.
+func gitRevisionAt(dir string, t time.Time) (string, error) {
+	revision, err := gitHeadRevision(dir)
+	if err != nil {
+		return "", err
+	}
+	if revision == "" {
+		return "", fmt.Errorf("%w: %s is not a git repository", ErrNoHistory, dir)
+	}
+
+	out, err := command.New("git", "rev-list", "-1", "--before="+t.Format(time.RFC3339), "HEAD").SetDir(dir).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the revision of %s at %s: %s: %w", dir, t.Format(time.RFC3339), out, err)
+	}
+	return out, nil
+}
+
+// specAtRevision extracts the StepLib repository at the given revision to a temporary dir and reads its spec.
+func specAtRevision(dir, revision string, exportType ExportTypes) (models.StepCollectionModel, error) {
+	tmpDir, err := os.MkdirTemp("", "steplib")
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	if err := gitArchive(dir, revision, tmpDir); err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	return LoadLocalSteplib(tmpDir, exportType)
+}
+
+func gitArchive(dir, revision, destination string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", revision)
+	cmd.Dir = dir
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	extractErr := extractTar(stdout, destination)
+	// Drain the output, so git does not block on a full pipe if the extraction failed.
+	_, _ = io.Copy(io.Discard, stdout)
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to archive %s at %s: %s: %w", dir, revision, stderr.String(), err)
+	}
+	return extractErr
+}
+
+func extractTar(r io.Reader, destination string) error {
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		pth := filepath.Join(destination, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(pth, filepath.Clean(destination)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(pth, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(pth, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tarReader); err != nil {
+				_ = file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
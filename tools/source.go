@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/bitrise-io/stepman/stepman"
+)
+
+// SteplibSource provides the spec of a StepLib.
+type SteplibSource interface {
+	// URI identifies the StepLib (collection URI, directory or file path).
+	URI() string
+	// Spec returns the StepLib spec stripped down to the given export type.
+	Spec(exportType ExportTypes) (models.StepCollectionModel, error)
+}
+
+// NewSteplibSource selects the SteplibSource implementation based on the given URI:
+// an existing directory is treated as a local StepLib checkout, an existing file as a pre-exported spec.json,
+// anything else as a StepLib collection URI handled by stepman.
+func NewSteplibSource(uri string) (SteplibSource, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("steplib not defined")
+	}
+
+	pth, err := filepath.Abs(strings.TrimPrefix(uri, "file://"))
+	if err != nil {
+		return nil, err
+	}
+
+	if exist, err := pathutil.IsDirExists(pth); err != nil {
+		return nil, err
+	} else if exist {
+		return LocalSteplibSource{Dir: pth}, nil
+	}
+
+	if exist, err := pathutil.IsPathExists(pth); err != nil {
+		return nil, err
+	} else if exist {
+		return SpecFileSource{Path: pth}, nil
+	}
+
+	if strings.HasPrefix(uri, "file://") {
+		return nil, fmt.Errorf("steplib does not exist at: %s", pth)
+	}
+
+	return StepmanSource{CollectionURI: uri}, nil
+}
+
+// StepmanSource reads the spec of a StepLib collection through the stepman binary.
+type StepmanSource struct {
+	CollectionURI string
+}
+
+// URI ...
+func (s StepmanSource) URI() string {
+	return s.CollectionURI
+}
+
+// Spec ...
+func (s StepmanSource) Spec(exportType ExportTypes) (models.StepCollectionModel, error) {
+	if err := s.Update(); err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	return s.LocalSpec(exportType)
+}
+
+// Update updates stepman's local StepLib clone.
+func (s StepmanSource) Update() error {
+	return StepmanUpdate(s.CollectionURI)
+}
+
+// LocalSpec exports the spec of stepman's local StepLib clone without updating it.
+func (s StepmanSource) LocalSpec(exportType ExportTypes) (models.StepCollectionModel, error) {
+	return StepmanExportSpec(s.CollectionURI, exportType)
+}
+
+// Revision returns the commit hash of stepman's local StepLib clone, empty if the StepLib is not set up yet.
+func (s StepmanSource) Revision() (string, error) {
+	route, found := stepman.ReadRoute(s.CollectionURI)
+	if !found {
+		return "", nil
+	}
+
+	return gitRevision(stepman.GetLibraryBaseDirPath(route))
+}
+
+// LocalSteplibSource reads the spec from a local StepLib checkout.
+type LocalSteplibSource struct {
+	Dir string
+}
+
+// URI ...
+func (s LocalSteplibSource) URI() string {
+	return s.Dir
+}
+
+// Spec ...
+func (s LocalSteplibSource) Spec(exportType ExportTypes) (models.StepCollectionModel, error) {
+	return LoadLocalSteplib(s.Dir, exportType)
+}
+
+// Revision returns the commit hash of the StepLib checkout, if it is a git repository without local changes.
+func (s LocalSteplibSource) Revision() (string, error) {
+	return gitRevision(s.Dir)
+}
+
+// SpecFileSource reads the spec from a spec.json file exported by 'stepman export-spec --export-type full'.
+type SpecFileSource struct {
+	Path string
+}
+
+// URI ...
+func (s SpecFileSource) URI() string {
+	return s.Path
+}
+
+// Spec ...
+func (s SpecFileSource) Spec(exportType ExportTypes) (models.StepCollectionModel, error) {
+	specContentBytes, err := fileutil.ReadBytesFromFile(s.Path)
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	var steplib models.StepCollectionModel
+	if err := json.Unmarshal(specContentBytes, &steplib); err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	return ConvertSpec(steplib, exportType)
+}
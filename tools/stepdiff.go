@@ -0,0 +1,341 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/stepman/models"
+)
+
+// StepChangeKind ...
+type StepChangeKind string
+
+const (
+	// StepChangeInputRemoved ...
+	StepChangeInputRemoved StepChangeKind = "input_removed"
+	// StepChangeInputRenamed ...
+	StepChangeInputRenamed StepChangeKind = "input_renamed"
+	// StepChangeInputAdded ...
+	StepChangeInputAdded StepChangeKind = "input_added"
+	// StepChangeDefaultChanged ...
+	StepChangeDefaultChanged StepChangeKind = "default_changed"
+	// StepChangeRequiredChanged ...
+	StepChangeRequiredChanged StepChangeKind = "required_changed"
+	// StepChangeValueOptionsChanged ...
+	StepChangeValueOptionsChanged StepChangeKind = "value_options_changed"
+	// StepChangeOutputRemoved ...
+	StepChangeOutputRemoved StepChangeKind = "output_removed"
+	// StepChangeOutputRenamed ...
+	StepChangeOutputRenamed StepChangeKind = "output_renamed"
+	// StepChangeOutputAdded ...
+	StepChangeOutputAdded StepChangeKind = "output_added"
+	// StepChangeToolkitChanged ...
+	StepChangeToolkitChanged StepChangeKind = "toolkit_changed"
+	// StepChangeDepsChanged ...
+	StepChangeDepsChanged StepChangeKind = "deps_changed"
+)
+
+// StepChange is a change of a step's interface between two versions.
+type StepChange struct {
+	Kind     StepChangeKind `json:"kind"`
+	Key      string         `json:"key,omitempty"`
+	From     string         `json:"from,omitempty"`
+	To       string         `json:"to,omitempty"`
+	Breaking bool           `json:"breaking"`
+}
+
+// String ...
+func (c StepChange) String() string {
+	switch c.Kind {
+	case StepChangeInputRemoved:
+		return fmt.Sprintf("input removed: %s", c.Key)
+	case StepChangeInputRenamed:
+		return fmt.Sprintf("input renamed: %s -> %s", c.From, c.To)
+	case StepChangeInputAdded:
+		if c.Breaking {
+			return fmt.Sprintf("required input without default value added: %s", c.Key)
+		}
+		return fmt.Sprintf("input added: %s", c.Key)
+	case StepChangeDefaultChanged:
+		return fmt.Sprintf("default value of %s changed: %q -> %q", c.Key, c.From, c.To)
+	case StepChangeRequiredChanged:
+		return fmt.Sprintf("is_required of %s changed: %s -> %s", c.Key, c.From, c.To)
+	case StepChangeValueOptionsChanged:
+		return fmt.Sprintf("value options of %s changed: [%s] -> [%s]", c.Key, c.From, c.To)
+	case StepChangeOutputRemoved:
+		return fmt.Sprintf("output removed: %s", c.Key)
+	case StepChangeOutputRenamed:
+		return fmt.Sprintf("output renamed: %s -> %s", c.From, c.To)
+	case StepChangeOutputAdded:
+		return fmt.Sprintf("output added: %s", c.Key)
+	case StepChangeToolkitChanged:
+		return fmt.Sprintf("toolkit changed: %s -> %s", c.From, c.To)
+	case StepChangeDepsChanged:
+		return fmt.Sprintf("dependencies changed: [%s] -> [%s]", c.From, c.To)
+	}
+	return string(c.Kind)
+}
+
+type stepEnv struct {
+	key          string
+	defaultValue string
+	options      envmanModels.EnvironmentItemOptionsModel
+}
+
+// DiffSteps compares the interface (inputs, outputs, toolkit and dependencies) of two versions of a step.
+//
+// Breaking changes are the ones, which can break an existing workflow using the step:
+// removed or renamed inputs and outputs, inputs becoming required, new required inputs without default value
+// and removed value options.
+func DiffSteps(from, to models.StepModel) ([]StepChange, error) {
+	fromInputs, err := parseStepEnvs(from.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	toInputs, err := parseStepEnvs(to.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	fromOutputs, err := parseStepEnvs(from.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	toOutputs, err := parseStepEnvs(to.Outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []StepChange
+	changes = append(changes, diffInputs(fromInputs, toInputs)...)
+	changes = append(changes, diffOutputs(fromOutputs, toOutputs)...)
+
+	if fromToolkit, toToolkit := ToolkitName(from.Toolkit), ToolkitName(to.Toolkit); fromToolkit != toToolkit {
+		changes = append(changes, StepChange{Kind: StepChangeToolkitChanged, From: fromToolkit, To: toToolkit})
+	}
+
+	if fromDeps, toDeps := stepDependencies(from), stepDependencies(to); strings.Join(fromDeps, ",") != strings.Join(toDeps, ",") {
+		changes = append(changes, StepChange{Kind: StepChangeDepsChanged, From: strings.Join(fromDeps, ", "), To: strings.Join(toDeps, ", ")})
+	}
+
+	return changes, nil
+}
+
+// HasBreakingChange ...
+func HasBreakingChange(changes []StepChange) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+func diffInputs(from, to map[string]stepEnv) []StepChange {
+	var changes []StepChange
+
+	removed, added := removedAndAddedEnvKeys(from, to)
+	renamedTo := renamedEnvKeys(from, to, removed, added)
+	renamedFrom := map[string]bool{}
+	for _, removedKey := range removed {
+		for _, addedKey := range added {
+			if renamedTo[addedKey] == removedKey {
+				renamedFrom[removedKey] = true
+				changes = append(changes, StepChange{Kind: StepChangeInputRenamed, From: removedKey, To: addedKey, Breaking: true})
+			}
+		}
+	}
+
+	for _, key := range removed {
+		if renamedFrom[key] {
+			continue
+		}
+		changes = append(changes, StepChange{Kind: StepChangeInputRemoved, Key: key, Breaking: true})
+	}
+
+	for _, key := range added {
+		if _, ok := renamedTo[key]; ok {
+			continue
+		}
+		input := to[key]
+		changes = append(changes, StepChange{Kind: StepChangeInputAdded, Key: key, Breaking: isRequired(input.options) && input.defaultValue == ""})
+	}
+
+	for _, key := range sortedEnvKeys(from) {
+		fromInput := from[key]
+		toInput, ok := to[key]
+		if !ok {
+			continue
+		}
+
+		if fromInput.defaultValue != toInput.defaultValue {
+			changes = append(changes, StepChange{Kind: StepChangeDefaultChanged, Key: key, From: fromInput.defaultValue, To: toInput.defaultValue})
+		}
+
+		if fromRequired, toRequired := isRequired(fromInput.options), isRequired(toInput.options); fromRequired != toRequired {
+			changes = append(changes, StepChange{Kind: StepChangeRequiredChanged, Key: key, From: fmt.Sprint(fromRequired), To: fmt.Sprint(toRequired), Breaking: toRequired})
+		}
+
+		fromOptions, toOptions := fromInput.options.ValueOptions, toInput.options.ValueOptions
+		if strings.Join(fromOptions, ",") != strings.Join(toOptions, ",") {
+			// Removing a value option breaks the workflows using it, restricting a free text input to value options too.
+			breaking := len(toOptions) > 0 && (len(fromOptions) == 0 || !isSubset(fromOptions, toOptions))
+			changes = append(changes, StepChange{Kind: StepChangeValueOptionsChanged, Key: key, From: strings.Join(fromOptions, ", "), To: strings.Join(toOptions, ", "), Breaking: breaking})
+		}
+	}
+
+	return changes
+}
+
+func diffOutputs(from, to map[string]stepEnv) []StepChange {
+	var changes []StepChange
+
+	removed, added := removedAndAddedEnvKeys(from, to)
+	renamedTo := renamedEnvKeys(from, to, removed, added)
+	renamedFrom := map[string]bool{}
+	for _, removedKey := range removed {
+		for _, addedKey := range added {
+			if renamedTo[addedKey] == removedKey {
+				renamedFrom[removedKey] = true
+				changes = append(changes, StepChange{Kind: StepChangeOutputRenamed, From: removedKey, To: addedKey, Breaking: true})
+			}
+		}
+	}
+
+	for _, key := range removed {
+		if !renamedFrom[key] {
+			changes = append(changes, StepChange{Kind: StepChangeOutputRemoved, Key: key, Breaking: true})
+		}
+	}
+	for _, key := range added {
+		if _, ok := renamedTo[key]; !ok {
+			changes = append(changes, StepChange{Kind: StepChangeOutputAdded, Key: key})
+		}
+	}
+	return changes
+}
+
+// removedAndAddedEnvKeys returns the sorted keys of the envs missing from the new and from the old version.
+func removedAndAddedEnvKeys(from, to map[string]stepEnv) ([]string, []string) {
+	var removed, added []string
+	for _, key := range sortedEnvKeys(from) {
+		if _, ok := to[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	for _, key := range sortedEnvKeys(to) {
+		if _, ok := from[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	return removed, added
+}
+
+// renamedEnvKeys pairs the removed and the added envs by their title: an env is considered to be renamed,
+// if a removed and an added env has the same title. The returned map points from the new key to the old key.
+func renamedEnvKeys(from, to map[string]stepEnv, removed, added []string) map[string]string {
+	renamedTo := map[string]string{}
+	for _, removedKey := range removed {
+		removedTitle := StringValue(from[removedKey].options.Title)
+		if removedTitle == "" {
+			continue
+		}
+		for _, addedKey := range added {
+			if _, ok := renamedTo[addedKey]; ok {
+				continue
+			}
+			if StringValue(to[addedKey].options.Title) == removedTitle {
+				renamedTo[addedKey] = removedKey
+				break
+			}
+		}
+	}
+	return renamedTo
+}
+
+func parseStepEnvs(envs []envmanModels.EnvironmentItemModel) (map[string]stepEnv, error) {
+	parsed := map[string]stepEnv{}
+	for _, env := range envs {
+		key, value, err := env.GetKeyValuePair()
+		if err != nil {
+			return nil, err
+		}
+		options, err := env.GetOptions()
+		if err != nil {
+			return nil, err
+		}
+		parsed[key] = stepEnv{key: key, defaultValue: value, options: options}
+	}
+	return parsed, nil
+}
+
+func sortedEnvKeys(envs map[string]stepEnv) []string {
+	var keys []string
+	for key := range envs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func stepDependencies(step models.StepModel) []string {
+	var deps []string
+	for _, dep := range step.Dependencies {
+		deps = append(deps, dep.Manager+":"+dep.Name)
+	}
+	if step.Deps != nil {
+		for _, dep := range step.Deps.Brew {
+			deps = append(deps, "brew:"+dep.Name)
+		}
+		for _, dep := range step.Deps.AptGet {
+			deps = append(deps, "apt_get:"+dep.Name)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// ToolkitName returns the name of the step's toolkit, steps without toolkit are bash steps.
+func ToolkitName(toolkit *models.StepToolkitModel) string {
+	switch {
+	case toolkit == nil:
+		return "bash"
+	case toolkit.Go != nil:
+		return "go"
+	case toolkit.Swift != nil:
+		return "swift"
+	case toolkit.Kotlin != nil:
+		return "kotlin"
+	default:
+		return "bash"
+	}
+}
+
+func isRequired(options envmanModels.EnvironmentItemOptionsModel) bool {
+	return options.IsRequired != nil && *options.IsRequired
+}
+
+func isSubset(items, of []string) bool {
+	for _, item := range items {
+		found := false
+		for _, o := range of {
+			if o == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// StringValue returns the value of an optional string field of the step models, empty if it is not set.
+func StringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
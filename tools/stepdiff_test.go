@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-io/stepman/models"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDiffSteps(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []StepChange
+	}{
+		{
+			name: "no change",
+			from: `
+inputs:
+- branch: master
+  opts:
+    title: Branch
+outputs:
+- GIT_CLONE_COMMIT_HASH:
+`,
+			to: `
+inputs:
+- branch: master
+  opts:
+    title: Branch
+outputs:
+- GIT_CLONE_COMMIT_HASH:
+`,
+			want: nil,
+		},
+		{
+			name: "input removed and optional input added",
+			from: `
+inputs:
+- clone_depth: ""
+`,
+			to: `
+inputs:
+- fetch_tags: "no"
+`,
+			want: []StepChange{
+				{Kind: StepChangeInputRemoved, Key: "clone_depth", Breaking: true},
+				{Kind: StepChangeInputAdded, Key: "fetch_tags"},
+			},
+		},
+		{
+			name: "required input without default added",
+			from: `
+inputs: []
+`,
+			to: `
+inputs:
+- repository_url: ""
+  opts:
+    is_required: true
+`,
+			want: []StepChange{
+				{Kind: StepChangeInputAdded, Key: "repository_url", Breaking: true},
+			},
+		},
+		{
+			name: "input renamed",
+			from: `
+inputs:
+- clone_into_dir: $BITRISE_SOURCE_DIR
+  opts:
+    title: Clone destination (local) directory path
+`,
+			to: `
+inputs:
+- clone_dir: $BITRISE_SOURCE_DIR
+  opts:
+    title: Clone destination (local) directory path
+`,
+			want: []StepChange{
+				{Kind: StepChangeInputRenamed, From: "clone_into_dir", To: "clone_dir", Breaking: true},
+			},
+		},
+		{
+			name: "input default, required and value options changed",
+			from: `
+inputs:
+- update_submodules: "yes"
+  opts:
+    value_options: ["yes", "no", "recursive"]
+`,
+			to: `
+inputs:
+- update_submodules: "no"
+  opts:
+    is_required: true
+    value_options: ["yes", "no"]
+`,
+			want: []StepChange{
+				{Kind: StepChangeDefaultChanged, Key: "update_submodules", From: "yes", To: "no"},
+				{Kind: StepChangeRequiredChanged, Key: "update_submodules", From: "false", To: "true", Breaking: true},
+				{Kind: StepChangeValueOptionsChanged, Key: "update_submodules", From: "yes, no, recursive", To: "yes, no", Breaking: true},
+			},
+		},
+		{
+			name: "value option added",
+			from: `
+inputs:
+- merge: "yes"
+  opts:
+    value_options: ["yes", "no"]
+`,
+			to: `
+inputs:
+- merge: "yes"
+  opts:
+    value_options: ["yes", "no", "auto"]
+`,
+			want: []StepChange{
+				{Kind: StepChangeValueOptionsChanged, Key: "merge", From: "yes, no", To: "yes, no, auto"},
+			},
+		},
+		{
+			name: "outputs removed, renamed and added",
+			from: `
+outputs:
+- GIT_CLONE_COMMIT_HASH:
+  opts:
+    title: Cloned git commit's commit hash
+- GIT_CLONE_COMMIT_AUTHOR_NAME:
+`,
+			to: `
+outputs:
+- GIT_COMMIT_HASH:
+  opts:
+    title: Cloned git commit's commit hash
+- GIT_CLONE_COMMIT_MESSAGE_BODY:
+`,
+			want: []StepChange{
+				{Kind: StepChangeOutputRenamed, From: "GIT_CLONE_COMMIT_HASH", To: "GIT_COMMIT_HASH", Breaking: true},
+				{Kind: StepChangeOutputRemoved, Key: "GIT_CLONE_COMMIT_AUTHOR_NAME", Breaking: true},
+				{Kind: StepChangeOutputAdded, Key: "GIT_CLONE_COMMIT_MESSAGE_BODY"},
+			},
+		},
+		{
+			name: "toolkit and dependencies changed",
+			from: `
+toolkit:
+  bash:
+    entry_file: step.sh
+deps:
+  brew:
+  - name: git-lfs
+`,
+			to: `
+toolkit:
+  go:
+    package_name: github.com/bitrise-steplib/steps-git-clone
+`,
+			want: []StepChange{
+				{Kind: StepChangeToolkitChanged, From: "bash", To: "go"},
+				{Kind: StepChangeDepsChanged, From: "brew:git-lfs", To: ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffSteps(stepFromYAML(t, tt.from), stepFromYAML(t, tt.to))
+			if err != nil {
+				t.Fatalf("DiffSteps() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSteps() = %+v, want %+v", got, tt.want)
+			}
+			if gotBreaking, wantBreaking := HasBreakingChange(got), HasBreakingChange(tt.want); gotBreaking != wantBreaking {
+				t.Errorf("HasBreakingChange() = %v, want %v", gotBreaking, wantBreaking)
+			}
+		})
+	}
+}
+
+func stepFromYAML(t *testing.T, content string) models.StepModel {
+	var step models.StepModel
+	if err := yaml.Unmarshal([]byte(content), &step); err != nil {
+		t.Fatalf("invalid step: %v", err)
+	}
+	return step
+}
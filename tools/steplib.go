@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/urlutil"
+	"github.com/bitrise-io/stepman/models"
+	"github.com/bitrise-io/stepman/stepman"
+	ver "github.com/hashicorp/go-version"
+)
+
+const (
+	steplibSpecFileName = "steplib.yml"
+	stepsDirName        = "steps"
+	stepDefinitionName  = "step.yml"
+	stepInfoFileName    = "step-info.yml"
+	stepAssetsDirName   = "assets"
+)
+
+// LoadLocalSteplib reads a local StepLib checkout (steplib.yml and the steps/<id>/<version>/step.yml tree)
+// and builds the same spec, which 'stepman export-spec' would export, without calling the stepman binary.
+func LoadLocalSteplib(steplibDir string, exportType ExportTypes) (models.StepCollectionModel, error) {
+	templateCollection, err := stepman.ParseStepCollection(filepath.Join(steplibDir, steplibSpecFileName))
+	if err != nil {
+		return models.StepCollectionModel{}, fmt.Errorf("failed to parse %s: %w", steplibSpecFileName, err)
+	}
+
+	collection := models.StepCollectionModel{
+		FormatVersion:         templateCollection.FormatVersion,
+		GeneratedAtTimeStamp:  time.Now().Unix(),
+		SteplibSource:         templateCollection.SteplibSource,
+		DownloadLocations:     templateCollection.DownloadLocations,
+		AssetsDownloadBaseURI: templateCollection.AssetsDownloadBaseURI,
+		Steps:                 models.StepHash{},
+	}
+
+	stepsDir := filepath.Join(steplibDir, stepsDirName)
+	stepDirs, err := os.ReadDir(stepsDir)
+	if err != nil {
+		return models.StepCollectionModel{}, err
+	}
+
+	for _, stepDir := range stepDirs {
+		if !stepDir.IsDir() {
+			continue
+		}
+
+		stepID := stepDir.Name()
+		stepGroup, err := loadStepGroup(filepath.Join(stepsDir, stepID), stepID, collection.AssetsDownloadBaseURI)
+		if err != nil {
+			return models.StepCollectionModel{}, fmt.Errorf("failed to load step (%s): %w", stepID, err)
+		}
+		if len(stepGroup.Versions) == 0 {
+			continue
+		}
+
+		collection.Steps[stepID] = stepGroup
+	}
+
+	return ConvertSpec(collection, exportType)
+}
+
+func loadStepGroup(stepDir, stepID, assetsDownloadBaseURI string) (models.StepGroupModel, error) {
+	stepGroup := models.StepGroupModel{
+		Versions: map[string]models.StepModel{},
+	}
+
+	// STEPLIB_DIR/steps/step-id/step-info.yml
+	stepInfo, _, err := stepman.ParseStepGroupInfoModel(filepath.Join(stepDir, stepInfoFileName))
+	if err != nil {
+		return models.StepGroupModel{}, err
+	}
+	stepGroup.Info.RemovalDate = stepInfo.RemovalDate
+	stepGroup.Info.DeprecateNotes = stepInfo.DeprecateNotes
+	stepGroup.Info.Maintainer = stepInfo.Maintainer
+
+	// STEPLIB_DIR/steps/step-id/assets
+	var assetURLs map[string]string
+	if assetsDownloadBaseURI != "" {
+		assetURLs, err = collectAssetURLs(filepath.Join(stepDir, stepAssetsDirName), stepID, assetsDownloadBaseURI)
+		if err != nil {
+			return models.StepGroupModel{}, err
+		}
+		stepGroup.Info.AssetURLs = assetURLs
+	}
+
+	versionDirs, err := os.ReadDir(stepDir)
+	if err != nil {
+		return models.StepGroupModel{}, err
+	}
+
+	var latestVersion *ver.Version
+	for _, versionDir := range versionDirs {
+		if !versionDir.IsDir() || versionDir.Name() == stepAssetsDirName {
+			continue
+		}
+
+		// STEPLIB_DIR/steps/step-id/version/step.yml
+		stepDefinitionPth := filepath.Join(stepDir, versionDir.Name(), stepDefinitionName)
+		if exist, err := pathutil.IsPathExists(stepDefinitionPth); err != nil {
+			return models.StepGroupModel{}, err
+		} else if !exist {
+			continue
+		}
+
+		version, err := ver.NewVersion(versionDir.Name())
+		if err != nil {
+			return models.StepGroupModel{}, fmt.Errorf("invalid version (%s): %w", versionDir.Name(), err)
+		}
+
+		// The step definitions are validated like by the stepman spec export, so a checkout, which stepman rejects, is not loaded.
+		step, err := stepman.ParseStepDefinition(stepDefinitionPth, true)
+		if err != nil {
+			return models.StepGroupModel{}, fmt.Errorf("failed to parse %s: %w", stepDefinitionPth, err)
+		}
+		if assetURLs != nil {
+			step.AssetURLs = assetURLs
+		}
+
+		stepGroup.Versions[versionDir.Name()] = step
+
+		if latestVersion == nil || latestVersion.LessThan(version) {
+			latestVersion = version
+			stepGroup.LatestVersionNumber = versionDir.Name()
+		}
+	}
+
+	return stepGroup, nil
+}
+
+func collectAssetURLs(assetsDir, stepID, assetsDownloadBaseURI string) (map[string]string, error) {
+	if exist, err := pathutil.IsDirExists(assetsDir); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(assetsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	assetURLs := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		assetURL, err := urlutil.Join(assetsDownloadBaseURI, stepID, stepAssetsDirName, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		assetURLs[entry.Name()] = assetURL
+	}
+
+	return assetURLs, nil
+}
+
+// ConvertSpec strips a full StepLib spec down to the given export type, the same way 'stepman export-spec' does.
+func ConvertSpec(spec models.StepCollectionModel, exportType ExportTypes) (models.StepCollectionModel, error) {
+	switch exportType {
+	case ExportTypesFull:
+		return spec, nil
+	case ExportTypesLatest:
+		latestSteps := models.StepHash{}
+		for stepID, stepGroup := range spec.Steps {
+			latestVersionNumber, err := LatestVersionNumber(stepGroup)
+			if err != nil {
+				return models.StepCollectionModel{}, fmt.Errorf("step (%s): %w", stepID, err)
+			}
+
+			latestSteps[stepID] = models.StepGroupModel{
+				Info: stepGroup.Info,
+				Versions: map[string]models.StepModel{
+					latestVersionNumber: stepGroup.Versions[latestVersionNumber],
+				},
+			}
+		}
+		spec.Steps = latestSteps
+		return spec, nil
+	case ExportTypesMinimal:
+		minimalSteps := models.StepHash{}
+		for stepID := range spec.Steps {
+			minimalSteps[stepID] = models.StepGroupModel{}
+		}
+		spec.Steps = minimalSteps
+		return spec, nil
+	default:
+		return models.StepCollectionModel{}, fmt.Errorf("invalid export type (%s), available: [full, latest, minimal]", exportType)
+	}
+}
+
+// LatestVersionNumber returns the latest version of the step group.
+// Specs exported with the latest or minimal export type do not contain the latest_version_number field,
+// in this case the highest version is looked up from the available versions.
+func LatestVersionNumber(stepGroup models.StepGroupModel) (string, error) {
+	if stepGroup.LatestVersionNumber != "" {
+		return stepGroup.LatestVersionNumber, nil
+	}
+
+	latestVersionNumber := ""
+	var latestVersion *ver.Version
+	for versionNumber := range stepGroup.Versions {
+		version, err := ver.NewVersion(versionNumber)
+		if err != nil {
+			return "", err
+		}
+
+		if latestVersion == nil || latestVersion.LessThan(version) {
+			latestVersion = version
+			latestVersionNumber = versionNumber
+		}
+	}
+
+	if latestVersion == nil {
+		return "", fmt.Errorf("no version available")
+	}
+
+	return latestVersionNumber, nil
+}
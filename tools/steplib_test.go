@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bitrise-io/stepman/models"
+)
+
+func TestLocalSteplibSource_Spec(t *testing.T) {
+	tests := []struct {
+		name         string
+		exportType   ExportTypes
+		wantVersions map[string][]string
+		wantErr      bool
+	}{
+		{
+			name:       "full",
+			exportType: ExportTypesFull,
+			wantVersions: map[string][]string{
+				"git-clone": {"8.0.0", "8.1.0"},
+				"old-step":  {"1.0.0"},
+				"script":    {"1.1.5"},
+			},
+		},
+		{
+			name:       "latest",
+			exportType: ExportTypesLatest,
+			wantVersions: map[string][]string{
+				"git-clone": {"8.1.0"},
+				"old-step":  {"1.0.0"},
+				"script":    {"1.1.5"},
+			},
+		},
+		{
+			name:       "minimal",
+			exportType: ExportTypesMinimal,
+			wantVersions: map[string][]string{
+				"git-clone": nil,
+				"old-step":  nil,
+				"script":    nil,
+			},
+		},
+		{
+			name:       "invalid export type",
+			exportType: "partial",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := LocalSteplibSource{Dir: "testdata/steplib"}.Spec(tt.exportType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Spec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := stepVersions(spec.Steps); !reflect.DeepEqual(got, tt.wantVersions) {
+				t.Errorf("Spec() versions = %v, want %v", got, tt.wantVersions)
+			}
+		})
+	}
+}
+
+func TestLocalSteplibSource_StepGroup(t *testing.T) {
+	spec, err := LocalSteplibSource{Dir: "testdata/steplib"}.Spec(ExportTypesFull)
+	if err != nil {
+		t.Fatalf("Spec() error = %v", err)
+	}
+
+	if got := spec.SteplibSource; got != "https://github.com/bitrise-io/bitrise-steplib.git" {
+		t.Errorf("SteplibSource = %s", got)
+	}
+
+	gitClone := spec.Steps["git-clone"]
+	if gitClone.LatestVersionNumber != "8.1.0" {
+		t.Errorf("git-clone LatestVersionNumber = %s, want 8.1.0", gitClone.LatestVersionNumber)
+	}
+	if source := gitClone.Versions["8.1.0"].Source; source == nil || source.Commit != "1b2c3d4" {
+		t.Errorf("git-clone 8.1.0 Source = %v, want commit 1b2c3d4", source)
+	}
+
+	oldStep := spec.Steps["old-step"]
+	if oldStep.Info.DeprecateNotes != "Use script instead." || oldStep.Info.RemovalDate != "2024-03-03" {
+		t.Errorf("old-step Info = %+v, want the deprecation of step-info.yml", oldStep.Info)
+	}
+
+	wantAssetURLs := map[string]string{"icon.svg": "https://bitrise-steplib-collection.s3.amazonaws.com/steps/script/assets/icon.svg"}
+	if got := spec.Steps["script"].Info.AssetURLs; !reflect.DeepEqual(got, wantAssetURLs) {
+		t.Errorf("script AssetURLs = %v, want %v", got, wantAssetURLs)
+	}
+}
+
+func TestLocalSteplibSource_InvalidStepDefinition(t *testing.T) {
+	tests := []struct {
+		name           string
+		stepDefinition string
+		wantErr        bool
+	}{
+		{
+			name:           "valid",
+			stepDefinition: "title: Script\nsummary: Runs a script\nwebsite: https://github.com/bitrise-steplib/steps-script\npublished_at: 2023-01-10T10:00:00Z\nsource:\n  git: https://github.com/bitrise-steplib/steps-script.git\n  commit: 0a1b2c3\n",
+		},
+		{
+			name:           "missing source",
+			stepDefinition: "title: Script\nsummary: Runs a script\nwebsite: https://github.com/bitrise-steplib/steps-script\npublished_at: 2023-01-10T10:00:00Z\n",
+			wantErr:        true,
+		},
+		{
+			name:           "missing published_at",
+			stepDefinition: "title: Script\nsummary: Runs a script\nwebsite: https://github.com/bitrise-steplib/steps-script\nsource:\n  git: https://github.com/bitrise-steplib/steps-script.git\n  commit: 0a1b2c3\n",
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, filepath.Join(dir, "steplib.yml"), "format_version: 1.0.0\nsteplib_source: https://github.com/bitrise-io/bitrise-steplib.git\n")
+			writeTestFile(t, filepath.Join(dir, "steps", "script", "1.0.0", "step.yml"), tt.stepDefinition)
+
+			if _, err := (LocalSteplibSource{Dir: dir}).Spec(ExportTypesFull); (err != nil) != tt.wantErr {
+				t.Errorf("Spec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSpecFileSource_Spec(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		exportType   ExportTypes
+		wantVersions map[string][]string
+		wantErr      bool
+	}{
+		{
+			name:       "full",
+			path:       "testdata/spec.json",
+			exportType: ExportTypesFull,
+			wantVersions: map[string][]string{
+				"git-clone": {"8.0.0", "8.1.0"},
+				"script":    {"1.1.5"},
+			},
+		},
+		{
+			name:       "latest",
+			path:       "testdata/spec.json",
+			exportType: ExportTypesLatest,
+			wantVersions: map[string][]string{
+				"git-clone": {"8.1.0"},
+				"script":    {"1.1.5"},
+			},
+		},
+		{
+			name:       "not existing file",
+			path:       "testdata/missing.json",
+			exportType: ExportTypesFull,
+			wantErr:    true,
+		},
+		{
+			name:       "not a spec",
+			path:       "testdata/steplib/steplib.yml",
+			exportType: ExportTypesFull,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := SpecFileSource{Path: tt.path}.Spec(tt.exportType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Spec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := stepVersions(spec.Steps); !reflect.DeepEqual(got, tt.wantVersions) {
+				t.Errorf("Spec() versions = %v, want %v", got, tt.wantVersions)
+			}
+		})
+	}
+}
+
+func TestNewSteplibSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "local steplib dir", uri: "testdata/steplib", want: LocalSteplibSource{}},
+		{name: "spec file", uri: "file://testdata/spec.json", want: SpecFileSource{}},
+		{name: "collection URI", uri: "https://github.com/bitrise-io/bitrise-steplib.git", want: StepmanSource{}},
+		{name: "missing file", uri: "file://testdata/missing.json", wantErr: true},
+		{name: "empty", uri: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSteplibSource(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSteplibSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+				t.Errorf("NewSteplibSource() = %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
+
+// stepVersions returns the sorted versions by step ID.
+func stepVersions(steps models.StepHash) map[string][]string {
+	versions := map[string][]string{}
+	for stepID, stepGroup := range steps {
+		var stepVersions []string
+		for version := range stepGroup.Versions {
+			stepVersions = append(stepVersions, version)
+		}
+		sort.Strings(stepVersions)
+		versions[stepID] = stepVersions
+	}
+	return versions
+}
+
+func writeTestFile(t *testing.T, pth, content string) {
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const unifiedDiffContextLines = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns the unified diff of the two texts, like 'diff -u', or an empty string if they are equal.
+func UnifiedDiff(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while the changes are closer than two contexts.
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*unifiedDiffContextLines {
+				break
+			}
+		}
+
+		hunkStart := maxInt(start-unifiedDiffContextLines, 0)
+		hunkEnd := minInt(end+unifiedDiffContextLines, len(ops))
+
+		fromLine, toLine := 1, 1
+		for _, op := range ops[:hunkStart] {
+			if op.kind != '+' {
+				fromLine++
+			}
+			if op.kind != '-' {
+				toLine++
+			}
+		}
+
+		fromCount, toCount := 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				fromCount++
+			}
+			if op.kind != '-' {
+				toCount++
+			}
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			b.WriteByte('\n')
+		}
+
+		start = hunkEnd
+	}
+
+	return b.String()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the edit script of the longest common subsequence of the lines.
+func diffLines(from, to []string) []diffOp {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = maxInt(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			ops = append(ops, diffOp{kind: ' ', line: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', line: from[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		ops = append(ops, diffOp{kind: '-', line: from[i]})
+	}
+	for ; j < len(to); j++ {
+		ops = append(ops, diffOp{kind: '+', line: to[j]})
+	}
+	return ops
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
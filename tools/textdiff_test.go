@@ -0,0 +1,62 @@
+package tools
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			from: "a\nb\nc\n",
+			to:   "a\nB\nc\n",
+			want: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "added line to empty text",
+			from: "",
+			to:   "a\n",
+			want: "--- from\n+++ to\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "removed last line",
+			from: "a\nb\n",
+			to:   "a\n",
+			want: "--- from\n+++ to\n@@ -1,2 +1 @@\n a\n-b\n",
+		},
+		{
+			name: "context limited to three lines",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			to:   "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want: "--- from\n+++ to\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "distant changes in separate hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- from\n+++ to\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name: "close changes in one hunk",
+			from: "1\n2\n3\n4\n5\n6\n7\n",
+			to:   "one\n2\n3\n4\n5\n6\nseven\n",
+			want: "--- from\n+++ to\n@@ -1,7 +1,7 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n-7\n+seven\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("from", "to", tt.from, tt.to); got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
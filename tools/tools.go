@@ -2,6 +2,7 @@ package tools
 
 import (
 	"encoding/json"
+	"os"
 	"path/filepath"
 
 	"github.com/bitrise-io/go-utils/command"
@@ -34,6 +35,9 @@ func StepmanExportSpec(stepLibURI string, exportType ExportTypes) (models.StepCo
 	if err != nil {
 		return models.StepCollectionModel{}, err
 	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
 	specPth := filepath.Join(tmpDir, "spec.json")
 
 	exportCmd := command.New("stepman", "export-spec", "--steplib", stepLibURI, "--output", specPth, "--export-type", string(exportType))
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+
+	ver "github.com/hashicorp/go-version"
+)
+
+// SortVersionsDesc sorts the given semantic versions in descending order.
+func SortVersionsDesc(versions []string) ([]string, error) {
+	type parsedVersion struct {
+		original string
+		version  *ver.Version
+	}
+
+	parsedVersions := make([]parsedVersion, 0, len(versions))
+	for _, version := range versions {
+		v, err := ver.NewVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		parsedVersions = append(parsedVersions, parsedVersion{original: version, version: v})
+	}
+
+	sort.SliceStable(parsedVersions, func(i, j int) bool {
+		return parsedVersions[j].version.LessThan(parsedVersions[i].version)
+	})
+
+	sorted := make([]string, 0, len(parsedVersions))
+	for _, v := range parsedVersions {
+		sorted = append(sorted, v.original)
+	}
+	return sorted, nil
+}
+
+// BumpType ...
+type BumpType string
+
+const (
+	// BumpTypeMajor ...
+	BumpTypeMajor BumpType = "major"
+	// BumpTypeMinor ...
+	BumpTypeMinor BumpType = "minor"
+	// BumpTypePatch ...
+	BumpTypePatch BumpType = "patch"
+)
+
+// VersionBump tells which semantic version component was increased between the two versions.
+func VersionBump(from, to string) (BumpType, error) {
+	fromVersion, err := ver.NewVersion(from)
+	if err != nil {
+		return "", err
+	}
+	toVersion, err := ver.NewVersion(to)
+	if err != nil {
+		return "", err
+	}
+	if !fromVersion.LessThan(toVersion) {
+		return "", fmt.Errorf("%s is not greater than %s", to, from)
+	}
+
+	fromSegments, toSegments := fromVersion.Segments64(), toVersion.Segments64()
+	switch {
+	case fromSegments[0] != toSegments[0]:
+		return BumpTypeMajor, nil
+	case fromSegments[1] != toSegments[1]:
+		return BumpTypeMinor, nil
+	default:
+		return BumpTypePatch, nil
+	}
+}
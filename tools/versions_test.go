@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    BumpType
+		wantErr bool
+	}{
+		{name: "major", from: "8.3.1", to: "9.0.0", want: BumpTypeMajor},
+		{name: "minor", from: "8.3.1", to: "8.4.0", want: BumpTypeMinor},
+		{name: "patch", from: "8.3.1", to: "8.3.2", want: BumpTypePatch},
+		{name: "major with skipped minor reset", from: "1.9.9", to: "3.1.0", want: BumpTypeMajor},
+		{name: "short versions", from: "1", to: "1.1", want: BumpTypeMinor},
+		{name: "prerelease to release", from: "2.0.0-beta.1", to: "2.0.0", want: BumpTypePatch},
+		{name: "same version", from: "1.0.0", to: "1.0.0", wantErr: true},
+		{name: "downgrade", from: "2.0.0", to: "1.9.0", wantErr: true},
+		{name: "invalid from", from: "1.x", to: "2.0.0", wantErr: true},
+		{name: "invalid to", from: "1.0.0", to: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VersionBump(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VersionBump() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VersionBump() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortVersionsDesc(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "semantic order", versions: []string{"1.10.0", "1.9.0", "2.0.0", "1.2.3"}, want: []string{"2.0.0", "1.10.0", "1.9.0", "1.2.3"}},
+		{name: "prerelease before release", versions: []string{"2.0.0-beta", "2.0.0", "1.0.0"}, want: []string{"2.0.0", "2.0.0-beta", "1.0.0"}},
+		{name: "empty", versions: nil, want: []string{}},
+		{name: "invalid version", versions: []string{"1.0.0", "next"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SortVersionsDesc(tt.versions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SortVersionsDesc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortVersionsDesc() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}